@@ -26,4 +26,41 @@ type RequestObject struct {
 	// expressed by this file path will be used as the request body. The contents of the file
 	// are not processed in any way.
 	File string `json:"file,omitempty"`
+
+	// The maximum time to wait for the response, such as "500ms" or "10s". If empty, the
+	// default given with the --timeout command line option is used.
+	Timeout string `json:"timeout,omitempty"`
+
+	// If present, the TLS settings used to make the request, replacing the TLS settings from
+	// the dictionary.
+	TLS *TLSObject `json:"tls,omitempty"`
+
+	// If true, the cookie jar used by the test is emptied before the request is made, so no
+	// cookies saved from earlier responses are sent.
+	ClearCookies bool `json:"clearCookies,omitempty"`
+}
+
+// TLSObject describes the TLS settings used to connect to the server. Any field that is not
+// given uses the value from the dictionary, or the default if the dictionary has none.
+type TLSObject struct {
+	// If true, the certificate presented by the server must be valid for the host name. If
+	// false, any certificate is accepted.
+	Verify *bool `json:"verify,omitempty"`
+
+	// The path of a file containing the PEM-encoded certificates of the certificate authorities
+	// trusted to sign the server certificate, in place of the system certificate authorities.
+	CA string `json:"ca,omitempty"`
+
+	// The paths of the files containing the PEM-encoded client certificate and its private key,
+	// presented to a server that requires mutual TLS.
+	Cert string `json:"cert,omitempty"`
+	Key  string `json:"key,omitempty"`
+
+	// The minimum TLS version to use, such as "1.2". This can use dictionary substitutions, so
+	// it is checked when the request is made.
+	MinVersion string `json:"minVersion,omitempty"`
+
+	// The server name sent to the server and used to verify its certificate, in place of the
+	// host name of the endpoint.
+	ServerName string `json:"serverName,omitempty"`
 }
@@ -10,13 +10,106 @@ type ResponseObject struct {
 	// match the actual status code of the rest response.
 	Status int `json:"status" validate:"required,min=200,max=599"`
 
-	// IF present, the body of the response must EXACTLY match this string. This is rarely used in a test
-	// and instead the Test component is used instead to express elements of the expected response when it
-	// is a JSON object. This is also where the body is stored
+	// This is the HTTP status code actually received from the rest server. It is set when the
+	// test is run.
+	Received int `json:"-"`
+
+	// IF present, the body of the response must EXACTLY match this string, after dictionary substitution.
+	// When both are JSON, whitespace and the order of object keys are ignored. This is rarely used in a
+	// test and instead the Test component is used instead to express elements of the expected response
+	// when it is a JSON object. When the test is run, this is replaced with the actual response body.
 	Body string `json:"body"`
 
+	// If present, the response body must be JSON that conforms to this JSON Schema. This is either
+	// the schema expressed as a JSON object, or the path of a file containing the schema. Any
+	// schema files referenced with "$ref" must be within the test suite directory.
+	Schema interface{} `json:"schema,omitempty"`
+
+	// If present, the response body must match this type definition. Each field of the object is
+	// either a validator tag string (such as "type=integer,required,min=1"), a nested object that
+	// defines the fields of a nested object, or an array with a single element that defines the
+	// type of each element of an array.
+	ResponseType interface{} `json:"responseType,omitempty"`
+
+	// If present, the response body must match the body stored in a snapshot file.
+	Snapshot *SnapshotObject `json:"snapshot,omitempty"`
+
+	// If present, the TLS connection used for the request must match these expectations.
+	TLS *TLSResponseObject `json:"tls,omitempty"`
+
+	// This is a map of the cookies that the response must set, by name, and the expected
+	// attributes of each.
+	Cookies map[string]CookieObject `json:"cookies,omitempty"`
+
+	// These are the values of the cookies set by the response, by name. This is set when the
+	// test is run.
+	ReceivedCookies map[string]string `json:"-"`
+
+	// These are the headers of the response. This is set when the test is run.
+	ReceivedHeaders map[string][]string `json:"-"`
+
 	// This is a list of the items that should be extracted from the response body if it passes all the
 	// test requirements. The map defines key values for the substitution dictionary, and the value of the
-	// map are dot-notation strings that specify the items to extract.
+	// map are dot-notation strings that specify the items to extract. The value can instead be
+	// "response:status" for the response status code, "header:name" for the value of a response header,
+	// "cookie:name" for the value of a cookie set by the response, or "regex:pattern" for the first
+	// capture group of the pattern in the text of the response body.
 	Save map[string]string `json:"save,omitempty"`
 }
+
+// SnapshotObject describes the golden file that the response body is compared against. When
+// the body is JSON, the comparison ignores whitespace and the order of object keys.
+type SnapshotObject struct {
+	// The path of the snapshot file, relative to the directory containing the test. If empty,
+	// this is the name of the test file with the extension ".snapshot".
+	File string `json:"file,omitempty"`
+
+	// A list of dot-notation paths of values in the body that are not compared, such as ids or
+	// timestamps that change each time the test is run. A "*" in a path matches any object key
+	// or array index.
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// TLSResponseObject describes the expected TLS connection used to make the request, and the
+// certificate presented by the server.
+type TLSResponseObject struct {
+	// The TLS version that must be negotiated, such as "1.3". This can use dictionary
+	// substitutions, so it is checked when the response is validated.
+	Version string `json:"version,omitempty"`
+
+	// Text that the subject of the server certificate must contain, such as "CN=example.com".
+	Subject string `json:"subject,omitempty"`
+
+	// Text that the issuer of the server certificate must contain.
+	Issuer string `json:"issuer,omitempty"`
+
+	// The minimum time the server certificate must remain valid, such as "720h". A certificate
+	// that expires sooner fails the test.
+	ValidFor string `json:"validFor,omitempty"`
+}
+
+// CookieObject describes a cookie that the response must set with a Set-Cookie header. Any
+// field that is not given is not checked.
+type CookieObject struct {
+	// The value of the cookie, after dictionary substitution.
+	Value string `json:"value,omitempty"`
+
+	// If present, whether the cookie must have the Secure attribute.
+	Secure *bool `json:"secure,omitempty"`
+
+	// If present, whether the cookie must have the HttpOnly attribute.
+	HTTPOnly *bool `json:"httpOnly,omitempty"`
+
+	// The SameSite attribute the cookie must have, which is "strict", "lax", or "none".
+	SameSite string `json:"sameSite,omitempty"`
+
+	// The path and domain attributes the cookie must have.
+	Path   string `json:"path,omitempty"`
+	Domain string `json:"domain,omitempty"`
+
+	// If present, whether the cookie must be a session cookie, which has no expiry time.
+	Session *bool `json:"session,omitempty"`
+
+	// The minimum time the cookie must remain valid, such as "1h".
+	ValidFor string `json:"validFor,omitempty"`
+}
@@ -23,8 +23,42 @@ type Validation struct {
 	// 		"le"				less than or equal to
 	// 		"contains"			contains the string value of
 	// 		"not contains"		does not contain the string value of
+	// 		"matches"			matches the regular expression in the value
+	// 		"not matches"		does not match the regular expression in the value
+	// 		"startsWith"		starts with the string value of
+	// 		"endsWith"			ends with the string value of
+	// 		"eqi"				equal to, ignoring case
+	// 		"empty"				is an empty string, array, object, or null
+	// 		"not empty"			is not an empty string, array, object, or null
+	// 		"type"				is of the JSON type named in the value (string, number, integer,
+	// 							boolean, null, array, or object)
+	// 		"==="				equal to, including the type, where the value is JSON text
+	// 		"!=="				not equal to, including the type, where the value is JSON text
 	// 		"exists"			a value exists in the response at this location
 	Operator string `json:"op"`
+
+	// The quantifier used when the query finds more than one item, such as with a "*" wildcard.
+	// If empty, the operator is applied to the list of items as a whole. Otherwise, the operator
+	// is applied to each item individually, and the mode is one of:
+	// 		"all"				every item must pass
+	// 		"any"				at least one item must pass
+	// 		"none"				no item may pass
+	Mode string `json:"mode,omitempty" validate:"enum=all|any|none"`
+
+	// If true, and Mode is empty, this is the same as a Mode of "all".
+	Every bool `json:"every,omitempty"`
+}
+
+// ValidationResult records the outcome of an individual validation step when the test is run.
+type ValidationResult struct {
+	// The name of the validation.
+	Name string `json:"name"`
+
+	// True if the validation passed.
+	Passed bool `json:"passed"`
+
+	// If the validation failed, this is the text of the error.
+	Error string `json:"error,omitempty"`
 }
 
 // If all test validations pass, then the test will execute tasks as well. These
@@ -38,12 +72,65 @@ type Task struct {
 	Parameters []string `json:"parameters,omitempty"`
 }
 
+// Retry describes how a test is retried when it fails, for endpoints that are flaky or
+// eventually consistent.
+type Retry struct {
+	// The maximum number of times the request is made, including the first attempt.
+	Attempts int `json:"attempts" validate:"required,min=1"`
+
+	// The time to wait before the second attempt, such as "500ms" or "2s".
+	Delay string `json:"delay,omitempty"`
+
+	// The factor the delay is multiplied by after each attempt. If zero, the delay is the
+	// same for each attempt.
+	Backoff float64 `json:"backoff,omitempty"`
+
+	// The kinds of failure that are retried. If empty, every failure is retried. Valid
+	// kinds are:
+	// 		"connection"		the request could not be sent or no response was received
+	// 		"status"			the response status is one of the codes in Status
+	// 		"validation"		any check of the response failed, including the status
+	On []string `json:"on,omitempty"`
+
+	// The response status codes that are retried when On includes "status". If empty, any
+	// unexpected status is retried.
+	Status []int `json:"status,omitempty"`
+}
+
+// WaitUntil describes a condition the test waits for, for endpoints that report the state of
+// a background job. The request is made again at the interval until the condition is met.
+type WaitUntil struct {
+	// The validations that must all pass for the condition to be met. These use the same
+	// format as the Tests list of the test.
+	Tests []Validation `json:"tests" validate:"required"`
+
+	// The time to wait between requests, such as "500ms" or "2s". If empty, this is one second.
+	Interval string `json:"interval,omitempty"`
+
+	// The maximum time to wait for the condition, such as "30s" or "5m". If empty, this is
+	// thirty seconds.
+	Timeout string `json:"timeout,omitempty"`
+}
+
 // Test defines each individual test. This is the object that is stored in each physical test file
 // in the file system, and located using the "path" command line option.
 type Test struct {
 	// The name of the test, used for logging progress.
 	Description string `json:"description" validate:"required"`
 
+	// An optional identifier for the test, which other tests in the same directory can use
+	// to name it in their DependsOn list.
+	ID string `json:"id,omitempty"`
+
+	// A list of tags for the test, such as "smoke" or "slow", used to select the tests to run
+	// with the --tags command line option.
+	Tags []string `json:"tags,omitempty"`
+
+	// A list of the other tests in the same directory that must pass before this test is
+	// run, usually because this test uses values they save. Each is either the file name of
+	// the test (with or without the ".json" extension) or its ID.
+	DependsOn []string `json:"dependsOn,omitempty"`
+
 	// The description of the API rest call to make.
 	Request RequestObject `json:"request" validate:"required"`
 
@@ -56,15 +143,44 @@ type Test struct {
 	// A list of the tasks to execute if all test validations pass.
 	Tasks []Task `json:"tasks,omitempty"`
 
-	// A flag indicating if the test completed successfully. Currently not used.
+	// A flag indicating if the test completed successfully. This is set when the test is run.
 	Succeeded bool `json:"success"`
 
-	// A flag indicating the time the test was executed. Currently not used.
+	// The time the test was executed. This is set when the test is run.
 	Time time.Time `json:"time,omitempty"`
 
-	// A flag indicating the duration of the test execution. Currently not used.
+	// The duration of the REST call made by the test. This is set when the test is run.
 	Duration time.Duration `json:"duration,omitempty"`
 
+	// The outcome of each of the validations in Tests that was performed. This is set when
+	// the test is run.
+	Results []ValidationResult `json:"-"`
+
+	// For a scenario file, which contains a "steps" array instead of a single test, this is the
+	// outcome of each of the steps. This is set when the scenario is run.
+	Steps []Test `json:"-"`
+
+	// The number of the step, starting at 1, when the test is a step of a scenario. This is
+	// zero for a test file. This is set when the scenario is run.
+	Step int `json:"-"`
+
+	// The path of the file containing the test. This is set when the test is loaded.
+	File string `json:"-"`
+
+	// If present, the test is retried when it fails.
+	Retry *Retry `json:"retry,omitempty"`
+
+	// The number of attempts made to run the test. This is set when the test is run.
+	Attempts int `json:"-"`
+
+	// A flag indicating that the request of the test did not complete before its time limit
+	// or the deadline of the test run. This is set when the test is run.
+	TimedOut bool `json:"-"`
+
+	// If present, the request is made again until the condition is met, before the
+	// response is validated.
+	WaitUntil *WaitUntil `json:"waitUntil,omitempty"`
+
 	// A flag indicating that if this test fails, the rest of the tests should be skipped.
 	Abort bool `json:"abort,omitempty"`
 }
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/tucats/apitest/dictionary"
+)
+
+// testNode is a test file in a directory, along with the other test files in the directory
+// that it depends on.
+type testNode struct {
+	// The file name of the test, and its optional ID.
+	file string
+	id   string
+
+	// The names of the tests this test depends on, and the tests they refer to.
+	dependsOn []string
+	deps      []*testNode
+
+	// If there is a problem with the dependencies of the test, such as a dependency that
+	// does not exist or a cycle, this is the error. The test fails without being run.
+	err error
+
+	// True if the test is to be run, because it is selected by the --filter option or
+	// because a selected test depends on it.
+	selected bool
+
+	// The outcome of the test, and the dictionary it was run with, so the values it saved
+	// are available to the tests that depend on it.
+	status string
+	dict   *dictionary.Dictionary
+
+	// This is closed when the test has completed, when running in parallel.
+	done chan struct{}
+}
+
+// readGraph reads the id and dependsOn values of each of the test files in a directory, and
+// forms the graph of their dependencies. If none of the tests depend on another, the result
+// is nil and the tests are run in alphabetical order. The default tags of the directory are
+// used to select the tests to run.
+func readGraph(path string, fileNames, defaults []string) ([]*testNode, error) {
+	nodes := make([]*testNode, len(fileNames))
+	index := make(map[string]*testNode)
+	found := false
+
+	for i, name := range fileNames {
+		header, err := readHeader(path, name)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes[i] = &testNode{
+			file:      name,
+			id:        header.ID,
+			dependsOn: header.DependsOn,
+			done:      make(chan struct{}),
+		}
+
+		index[name] = nodes[i]
+		index[strings.TrimSuffix(name, filepath.Ext(name))] = nodes[i]
+		found = found || len(header.DependsOn) > 0
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	// A test can also be named by its ID, unless that is the name of another file.
+	for _, node := range nodes {
+		if _, exists := index[node.id]; node.id != "" && !exists {
+			index[node.id] = node
+		}
+	}
+
+	for _, node := range nodes {
+		for _, name := range node.dependsOn {
+			dep, exists := index[name]
+			if !exists {
+				node.err = fmt.Errorf("unknown dependency '%s'", name)
+
+				break
+			}
+
+			node.deps = append(node.deps, dep)
+		}
+	}
+
+	findCycles(nodes)
+
+	// Select the tests that match the filter and tags, along with the tests they depend on.
+	for _, node := range nodes {
+		if isSelected(path, node.file, defaults) {
+			node.selectWithDependencies()
+		}
+	}
+
+	return nodes, nil
+}
+
+// selectWithDependencies marks the test, and every test it depends on, to be run.
+func (node *testNode) selectWithDependencies() {
+	if node.selected {
+		return
+	}
+
+	node.selected = true
+
+	for _, dep := range node.deps {
+		dep.selectWithDependencies()
+	}
+}
+
+// findCycles finds the tests whose dependencies form a cycle, and sets the error for each
+// test in the cycle.
+func findCycles(nodes []*testNode) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	var (
+		stack []*testNode
+		visit func(node *testNode)
+		state = make(map[*testNode]int)
+	)
+
+	visit = func(node *testNode) {
+		state[node] = visiting
+		stack = append(stack, node)
+
+		for _, dep := range node.deps {
+			switch state[dep] {
+			case unvisited:
+				visit(dep)
+
+			case visiting:
+				// The dependency is on the stack, so the tests from it to the top of the
+				// stack form a cycle.
+				start := len(stack) - 1
+				for stack[start] != dep {
+					start--
+				}
+
+				names := make([]string, 0, len(stack)-start+1)
+				for _, member := range stack[start:] {
+					names = append(names, member.file)
+				}
+
+				err := fmt.Errorf("dependency cycle %s", strings.Join(append(names, dep.file), " -> "))
+
+				for _, member := range stack[start:] {
+					if member.err == nil {
+						member.err = err
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[node] = visited
+	}
+
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			visit(node)
+		}
+	}
+}
+
+// runGraph runs the selected tests of a directory in the order of their dependencies. When
+// the directory is sequential, the tests run one at a time sharing the directory dictionary,
+// in alphabetical order where their dependencies allow. Otherwise, each test runs as soon as
+// the tests it depends on have completed, with a copy of the directory dictionary that
+// includes the values saved by those tests.
+func runGraph(dict *dictionary.Dictionary, path string, nodes []*testNode, directory *skipState) error {
+	var (
+		lastErr error
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+	)
+
+	if isSequential(dict) {
+		for {
+			node := nextNode(nodes)
+			if node == nil {
+				break
+			}
+
+			if err := runNode(dict, path, node, directory); err != nil {
+				lastErr = err
+			}
+		}
+
+		return lastErr
+	}
+
+	for _, node := range nodes {
+		if !node.selected {
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer close(node.done)
+
+			nodeDict := dict.Clone()
+
+			if node.err == nil {
+				for _, dep := range node.deps {
+					<-dep.done
+
+					if dep.dict != nil {
+						nodeDict.Merge(dep.dict)
+					}
+				}
+			}
+
+			if err := runNode(nodeDict, path, node, directory); err != nil {
+				lock.Lock()
+				defer lock.Unlock()
+
+				lastErr = err
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return lastErr
+}
+
+// nextNode returns the first selected test, in alphabetical order, that has not been run
+// and whose dependencies have all completed. If there is none, the result is nil.
+func nextNode(nodes []*testNode) *testNode {
+	for _, node := range nodes {
+		if !node.selected || node.status != "" {
+			continue
+		}
+
+		ready := true
+
+		if node.err == nil {
+			for _, dep := range node.deps {
+				if dep.status == "" {
+					ready = false
+
+					break
+				}
+			}
+		}
+
+		if ready {
+			return node
+		}
+	}
+
+	return nil
+}
+
+// runNode runs a single test of the dependency graph. A test with an error in its
+// dependencies fails without being run, and a test that depends on a test that did not pass
+// is skipped.
+func runNode(dict *dictionary.Dictionary, path string, node *testNode, directory *skipState) error {
+	file := filepath.Join(path, node.file)
+
+	if node.err != nil {
+		node.status = "fail"
+
+		reportResult(path, file, nil, node.err)
+
+		return node.err
+	}
+
+	for _, dep := range node.deps {
+		if dep.status != "pass" {
+			outcome := "failed"
+			if dep.status == "skip" {
+				outcome = "was skipped"
+			}
+
+			node.status = "skip"
+
+			reportSkipped(path, file, fmt.Sprintf("depends on %s, which %s", dep.file, outcome))
+
+			return nil
+		}
+	}
+
+	if reason := skipReason(directory); reason != "" {
+		node.status = "skip"
+
+		reportSkipped(path, file, reason)
+
+		return nil
+	}
+
+	err := runFile(dict, path, node.file, directory)
+
+	node.dict = dict
+	node.status = "pass"
+
+	if isAbort(dict, err) {
+		node.status = "skip"
+	} else if err != nil {
+		node.status = "fail"
+	}
+
+	return err
+}
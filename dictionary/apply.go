@@ -11,13 +11,13 @@ import (
 	"github.com/tucats/apitest/formats"
 )
 
-// Apply applies the substitutions to the given text from the active dictionary. The
+// Apply applies the substitutions to the given text from the given dictionary. The
 // result is returned as a new string with the substitutions applied. If there were
 // no substitutions made, the original text is returned.
-func Apply(text string) string {
+func Apply(dictionary *Dictionary, text string) string {
 	subs := make(map[string]interface{})
 
-	for key, value := range Dictionary {
+	for key, value := range dictionary.snapshot() {
 		// Handle special cases for values.
 		switch {
 		case value == "$uuid":
@@ -37,7 +37,7 @@ func Apply(text string) string {
 				fmt.Printf("failed to read file: %v\n", err)
 			}
 
-			value = Apply(string(data))
+			value = Apply(dictionary, string(data))
 
 		case value == "$seq":
 			value = strconv.Itoa(int(sequence.Add(1)))
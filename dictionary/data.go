@@ -1,18 +1,78 @@
 package dictionary
 
 import (
+	"sync"
 	"sync/atomic"
 )
 
-// Dictionary is a shared map of substitutions that can be used across multiple tests.
-// This maintains state for the duration of test operation, so values can be passed from
-// one test result to another's test request definition, etc.
+// Dictionary is a map of substitutions that can be used across multiple tests. This
+// maintains state for the duration of a test run (or of one parallel branch of a run),
+// so values can be passed from one test result to another's test request definition, etc.
 //
 // Dictonary substutution is applied to URLs, request bodies, headers, parameters, and task
 // parameters.
-var Dictionary = make(map[string]string)
+type Dictionary struct {
+	values map[string]string
+	lock   sync.RWMutex
+}
 
 // This atomic integer counter is used to handle the "$seq" substitution which injects the next
 // available sequence number starting with zero. This is typically used for substitution values
-// that need to generate a unique identifier or value.
+// that need to generate a unique identifier or value. It is shared by all dictionaries so values
+// remain unique even when tests run in parallel.
 var sequence = atomic.Int32{}
+
+// New creates a new, empty dictionary.
+func New() *Dictionary {
+	return &Dictionary{values: make(map[string]string)}
+}
+
+// Get returns the value of the given key, and a flag indicating if the key was found.
+func (d *Dictionary) Get(key string) (string, bool) {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	value, ok := d.values[key]
+
+	return value, ok
+}
+
+// Set adds or replaces the value of the given key.
+func (d *Dictionary) Set(key, value string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.values[key] = value
+}
+
+// Clone creates a copy of the dictionary. This is used to give each parallel branch of a
+// test run its own dictionary, so values saved by one branch are not visible to another.
+func (d *Dictionary) Clone() *Dictionary {
+	return &Dictionary{values: d.snapshot()}
+}
+
+// snapshot returns a copy of the current key/value pairs in the dictionary.
+func (d *Dictionary) snapshot() map[string]string {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+
+	values := make(map[string]string, len(d.values))
+	for key, value := range d.values {
+		values[key] = value
+	}
+
+	return values
+}
+
+// Merge copies all the key/value pairs of the other dictionary into this one, replacing any
+// values this dictionary already has for the same keys.
+func (d *Dictionary) Merge(other *Dictionary) {
+	values := other.snapshot()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for key, value := range values {
+		d.values[key] = value
+	}
+}
@@ -13,8 +13,9 @@ import (
 	"github.com/tucats/apitest/parser"
 )
 
-// Attempt to load a dictionary definition from an external JSON file.
-func Load(filePath string) error {
+// Attempt to load a dictionary definition from an external JSON file, adding
+// the definitions to the given dictionary.
+func Load(dictionary *Dictionary, filePath string) error {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return err
@@ -22,13 +23,13 @@ func Load(filePath string) error {
 
 	data = parser.RemoveComments(data)
 
-	var dictionary map[string]string
+	var definitions map[string]string
 
-	if err := json.Unmarshal(data, &dictionary); err != nil {
+	if err := json.Unmarshal(data, &definitions); err != nil {
 		return err
 	}
 
-	for key, value := range dictionary {
+	for key, value := range definitions {
 		// Handle some special cases for values. "$uuid" is a reserved item for a
 		// generated UUID that is created during initialization. Also, any substitution
 		// string starting with "$" is looked up as an environment variable, and if non-empty
@@ -51,7 +52,7 @@ func Load(filePath string) error {
 				return fmt.Errorf("failed to read file: %w", err)
 			}
 
-			value = Apply(string(data))
+			value = Apply(dictionary, string(data))
 
 		case strings.HasPrefix(value, "$json "):
 		case strings.HasPrefix(value, "$"):
@@ -61,11 +62,11 @@ func Load(filePath string) error {
 			}
 		}
 
-		Dictionary[key] = value
+		dictionary.Set(key, value)
 	}
 
 	if logging.Verbose {
-		fmt.Printf("Loaded %d definitions from %s\n", len(dictionary), filePath)
+		fmt.Printf("Loaded %d definitions from %s\n", len(definitions), filePath)
 	}
 
 	return nil
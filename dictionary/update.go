@@ -2,25 +2,49 @@ package dictionary
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/tucats/apitest/logging"
 	"github.com/tucats/apitest/parser"
 )
 
-// Update will updated (or add) items in the dictionary from the text
-// of the response body, which is assumed to be a valid JSON object.
-// For each item in the map, the key is used as the name of the item
-// to add or update the item in the dictionary. The value of the key
-// is a dot-notation string that specifies the item to extract from
-// the JSON response body object.
-func Update(text string, items map[string]string) error {
+// Response is the part of the response to a test request that values can be saved from.
+type Response struct {
+	// The text of the response body.
+	Body string
+
+	// The HTTP status code of the response.
+	Status int
+
+	// The headers of the response.
+	Headers map[string][]string
+
+	// The values of the cookies set by the response, by name.
+	Cookies map[string]string
+}
+
+// Update will updated (or add) items in the given dictionary from the
+// response. For each item in the map, the key is used as the name of
+// the item to add or update the item in the dictionary. The value of
+// the key is the source of the value, which is one of:
+//
+//	response:status	the status code of the response
+//	header:name		the value of the named response header
+//	cookie:name		the value of the named cookie set by the response
+//	regex:pattern	the first capture group of the pattern in the response body
+//
+// Otherwise, the value is a dot-notation string that specifies the item
+// to extract from the response body, which must be a JSON object.
+func Update(dictionary *Dictionary, response Response, items map[string]string) error {
 	for key, value := range items {
-		item, err := parser.GetOneItem(text, value)
+		item, err := getItem(response, value)
 		if err != nil {
 			return err
 		}
 
-		Dictionary[key] = item
+		dictionary.Set(key, item)
 
 		if logging.Verbose {
 			if key == "API_TOKEN" {
@@ -33,3 +57,55 @@ func Update(text string, items map[string]string) error {
 
 	return nil
 }
+
+// getItem extracts the value to save from the response.
+func getItem(response Response, source string) (string, error) {
+	if source == "response:status" {
+		return strconv.Itoa(response.Status), nil
+	}
+
+	if name, ok := strings.CutPrefix(source, "header:"); ok {
+		for key, values := range response.Headers {
+			if strings.EqualFold(key, name) {
+				return strings.Join(values, ", "), nil
+			}
+		}
+
+		return "", fmt.Errorf("header '%s' was not in the response", name)
+	}
+
+	if name, ok := strings.CutPrefix(source, "cookie:"); ok {
+		value, found := response.Cookies[name]
+		if !found {
+			return "", fmt.Errorf("cookie '%s' was not set by the response", name)
+		}
+
+		return value, nil
+	}
+
+	if pattern, ok := strings.CutPrefix(source, "regex:"); ok {
+		return match(response.Body, pattern)
+	}
+
+	return parser.GetOneItem(response.Body, source)
+}
+
+// match finds the first match of the regular expression in the text, and returns the first
+// capture group of the match, or the whole match if the expression has no capture groups.
+func match(text, pattern string) (string, error) {
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid regular expression '%s': %v", pattern, err)
+	}
+
+	matches := expression.FindStringSubmatch(text)
+	if matches == nil {
+		return "", fmt.Errorf("regular expression '%s' did not match the response body", pattern)
+	}
+
+	if len(matches) > 1 {
+		return matches[1], nil
+	}
+
+	return matches[0], nil
+}
@@ -0,0 +1,111 @@
+package dictionary
+
+import (
+	"testing"
+)
+
+func TestUpdate(t *testing.T) {
+	response := Response{
+		Body:   `{ "status": "done", "id": 42, "cursor": "abc" }`,
+		Status: 200,
+		Headers: map[string][]string{
+			"Location": {"/users/42"},
+			"Link":     {`</users?page=2>; rel="next"`, `</users?page=9>; rel="last"`},
+		},
+		Cookies: map[string]string{"session": "abc123"},
+	}
+
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "body field",
+			source: "id",
+			want:   "42",
+		},
+		{
+			name:   "body field named status",
+			source: "status",
+			want:   "done",
+		},
+		{
+			name:   "response status",
+			source: "response:status",
+			want:   "200",
+		},
+		{
+			name:   "header",
+			source: "header:Location",
+			want:   "/users/42",
+		},
+		{
+			name:   "header ignores case",
+			source: "header:location",
+			want:   "/users/42",
+		},
+		{
+			name:   "header with multiple values",
+			source: "header:Link",
+			want:   `</users?page=2>; rel="next", </users?page=9>; rel="last"`,
+		},
+		{
+			name:    "missing header",
+			source:  "header:X-Missing",
+			wantErr: true,
+		},
+		{
+			name:   "cookie",
+			source: "cookie:session",
+			want:   "abc123",
+		},
+		{
+			name:    "missing cookie",
+			source:  "cookie:theme",
+			wantErr: true,
+		},
+		{
+			name:   "regex capture group",
+			source: `regex:"cursor": "(\w+)"`,
+			want:   "abc",
+		},
+		{
+			name:   "regex without capture group",
+			source: `regex:"id": \d+`,
+			want:   `"id": 42`,
+		},
+		{
+			name:    "regex without match",
+			source:  `regex:"missing": (\w+)`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid regex",
+			source:  `regex:(`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dict := New()
+
+			err := Update(dict, response, map[string]string{"VALUE": tt.source})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if got, _ := dict.Get("VALUE"); got != tt.want {
+				t.Errorf("Update() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Package diff compares two JSON values structurally, reporting each location where
+// they differ. Object keys are compared without regard to their order.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind describes how a value differs between the expected and actual JSON values.
+type Kind string
+
+const (
+	// Added is a value in the actual JSON that is not in the expected JSON.
+	Added Kind = "added"
+
+	// Removed is a value in the expected JSON that is not in the actual JSON.
+	Removed Kind = "removed"
+
+	// Changed is a value that is present in both, but is different.
+	Changed Kind = "changed"
+)
+
+// Difference is a single location where the expected and actual JSON values differ.
+type Difference struct {
+	// The dot-notation path of the value, in the same form used by test queries. The
+	// path of the top-level value is ".".
+	Path string
+
+	// How the value differs.
+	Kind Kind
+
+	// The expected value, which is nil for an added value.
+	Expected interface{}
+
+	// The actual value, which is nil for a removed value.
+	Actual interface{}
+}
+
+// String formats the difference as a single line of text.
+func (d Difference) String() string {
+	switch d.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", d.Path, text(d.Actual))
+
+	case Removed:
+		return fmt.Sprintf("- %s: %s", d.Path, text(d.Expected))
+
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", d.Path, text(d.Expected), text(d.Actual))
+	}
+}
+
+// Compare returns the differences between the expected and actual values, which are
+// the result of unmarshalling JSON text. Any value whose path matches one of the ignore
+// patterns, or is within a value that does, is not compared. A pattern is a dot-notation
+// path where a "*" matches any single object key or array index.
+func Compare(expected, actual interface{}, ignore []string) []Difference {
+	patterns := make([][]string, len(ignore))
+	for i, pattern := range ignore {
+		patterns[i] = split(pattern)
+	}
+
+	return compare(nil, expected, actual, patterns)
+}
+
+// Format returns the differences as text, with one difference per line.
+func Format(differences []Difference) string {
+	lines := make([]string, len(differences))
+	for i, difference := range differences {
+		lines[i] = difference.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// compare is the recursive comparison of the values at the given path.
+func compare(path []string, expected, actual interface{}, ignore [][]string) []Difference {
+	if ignored(path, ignore) {
+		return nil
+	}
+
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		var result []Difference
+
+		keys := make([]string, 0, len(e)+len(a))
+		for key := range e {
+			keys = append(keys, key)
+		}
+
+		for key := range a {
+			if _, found := e[key]; !found {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			result = append(result, member(append(path, key), e, a, key, ignore)...)
+		}
+
+		return result
+
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			break
+		}
+
+		var result []Difference
+
+		for index := 0; index < len(e) || index < len(a); index++ {
+			elementPath := append(path, strconv.Itoa(index))
+
+			switch {
+			case index >= len(a):
+				if !ignored(elementPath, ignore) {
+					result = append(result, Difference{Path: join(elementPath), Kind: Removed, Expected: e[index]})
+				}
+
+			case index >= len(e):
+				if !ignored(elementPath, ignore) {
+					result = append(result, Difference{Path: join(elementPath), Kind: Added, Actual: a[index]})
+				}
+
+			default:
+				result = append(result, compare(elementPath, e[index], a[index], ignore)...)
+			}
+		}
+
+		return result
+
+	default:
+		if equal(expected, actual) {
+			return nil
+		}
+	}
+
+	return []Difference{{Path: join(path), Kind: Changed, Expected: expected, Actual: actual}}
+}
+
+// member compares the values of a key that may be in either or both of the objects.
+func member(path []string, expected, actual map[string]interface{}, key string, ignore [][]string) []Difference {
+	e, inExpected := expected[key]
+	a, inActual := actual[key]
+
+	switch {
+	case !inActual:
+		if !ignored(path, ignore) {
+			return []Difference{{Path: join(path), Kind: Removed, Expected: e}}
+		}
+
+	case !inExpected:
+		if !ignored(path, ignore) {
+			return []Difference{{Path: join(path), Kind: Added, Actual: a}}
+		}
+
+	default:
+		return compare(path, e, a, ignore)
+	}
+
+	return nil
+}
+
+// equal compares two scalar JSON values.
+func equal(expected, actual interface{}) bool {
+	switch e := expected.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+
+	case nil:
+		return actual == nil
+
+	default:
+		return e == actual
+	}
+}
+
+// ignored determines if the path matches, or is within a value that matches, any of
+// the ignore patterns.
+func ignored(path []string, ignore [][]string) bool {
+	for _, pattern := range ignore {
+		if len(pattern) > len(path) {
+			continue
+		}
+
+		matched := true
+
+		for i, part := range pattern {
+			if part != "*" && part != path[i] {
+				matched = false
+
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// split breaks a dot-notation path into its parts, where a "\." is a dot within a part.
+func split(path string) []string {
+	if path == "." || path == "" {
+		return []string{}
+	}
+
+	parts := strings.Split(strings.ReplaceAll(path, `\.`, "\x00"), ".")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(part, "\x00", ".")
+	}
+
+	return parts
+}
+
+// join forms the dot-notation path from its parts, escaping any dots within a part.
+func join(path []string) string {
+	if len(path) == 0 {
+		return "."
+	}
+
+	parts := make([]string, len(path))
+	for i, part := range path {
+		parts[i] = strings.ReplaceAll(part, ".", `\.`)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// text formats a value as JSON for display in a difference.
+func text(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(b)
+}
@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		ignore   []string
+		want     []string
+	}{
+		{
+			name:     "same values in a different order",
+			expected: `{"a": 1, "b": [true, null, "x"]}`,
+			actual:   `{"b": [true, null, "x"], "a": 1.0}`,
+		},
+		{
+			name:     "changed value",
+			expected: `{"user": {"name": "Alice", "age": 30}}`,
+			actual:   `{"user": {"name": "Bob", "age": 30}}`,
+			want:     []string{`~ user.name: "Alice" -> "Bob"`},
+		},
+		{
+			name:     "added and removed keys",
+			expected: `{"a": 1, "b": 2}`,
+			actual:   `{"b": 2, "c": {"d": 3}}`,
+			want:     []string{`- a: 1`, `+ c: {"d":3}`},
+		},
+		{
+			name:     "array length",
+			expected: `[1, 2, 3]`,
+			actual:   `[1, 5]`,
+			want:     []string{`~ 1: 2 -> 5`, `- 2: 3`},
+		},
+		{
+			name:     "changed type",
+			expected: `{"a": [1]}`,
+			actual:   `{"a": {"0": 1}}`,
+			want:     []string{`~ a: [1] -> {"0":1}`},
+		},
+		{
+			name:     "top level value",
+			expected: `"one"`,
+			actual:   `"two"`,
+			want:     []string{`~ .: "one" -> "two"`},
+		},
+		{
+			name:     "ignored paths",
+			expected: `{"id": 1, "items": [{"id": 2, "name": "x"}, {"id": 3, "name": "y"}], "meta": {"time": "a"}}`,
+			actual:   `{"id": 9, "items": [{"id": 8, "name": "x"}, {"id": 7, "name": "z"}], "meta": {"time": "b", "host": "h"}}`,
+			ignore:   []string{"id", "items.*.id", "meta"},
+			want:     []string{`~ items.1.name: "y" -> "z"`},
+		},
+		{
+			name:     "escaped dots in keys",
+			expected: `{"a.b": 1, "c.d": 1}`,
+			actual:   `{"a.b": 2, "c.d": 2}`,
+			ignore:   []string{`c\.d`},
+			want:     []string{`~ a\.b: 1 -> 2`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var expected, actual interface{}
+
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := json.Unmarshal([]byte(tt.actual), &actual); err != nil {
+				t.Fatal(err)
+			}
+
+			differences := Compare(expected, actual, tt.ignore)
+			if len(differences) != len(tt.want) {
+				t.Fatalf("Compare() = %v, want %v", differences, tt.want)
+			}
+
+			for i, difference := range differences {
+				if difference.String() != tt.want[i] {
+					t.Errorf("Compare()[%d] = %s, want %s", i, difference.String(), tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -14,10 +14,22 @@ usage: apitest [options] test-path [test-path...]
 
 options:
 
+      --all-errors          Report every failed validation of a test, not just the first
   -d, --dictionary <file>   Add this dictionary file to the test dictionary
+      --deadline <duration> Stop the test run after this long, such as "10m"
+      --fail-fast           Skip the rest of the tests after the first test that fails
   -f, --filter <string>     Only run tests that contain the given string in their names
+      --first-error         Stop testing at the first failed validation of a test
   -h, --help                Show this help message and exit
+  -j, --parallel <count>    Run up to this many tests at the same time
+      --junit <file>        Write a JUnit XML report of the test results to the file
+      --junit-bodies        Include request and response bodies in the JUnit report
+      --no-reuse            Make each request on a new connection to the server
+      --report-json <file>  Write a JSON report of the test results to the file
   -r, --rest                Enable REST logging, which displays the text of each JSON response
+      --tags <expression>   Only run tests whose tags match the expression, such as "smoke && !slow"
+      --timeout <duration>  Stop waiting for each response after this long, such as "30s"
+      --update-snapshots    Rewrite snapshot files from the actual response bodies
   -v, --verbose             Enable verbose logging output
   -x, --define <key=value>  Define a value for a variable in the test dictionary (can be repeated)
   
@@ -25,8 +37,8 @@ options:
   in the test path directory tree.
   `
 
-func help() {
-	fmt.Println(dictionary.Apply(helpText))
+func help(dict *dictionary.Dictionary) {
+	fmt.Println(dictionary.Apply(dict, helpText))
 
 	os.Exit(0)
 }
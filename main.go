@@ -6,7 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/tucats/validator"
@@ -14,19 +17,68 @@ import (
 	"github.com/tucats/apitest/dictionary"
 	"github.com/tucats/apitest/formats"
 	"github.com/tucats/apitest/logging"
+	"github.com/tucats/apitest/report"
+	"github.com/tucats/apitest/tags"
+	"github.com/tucats/apitest/tester"
 )
 
 var BuildVersion = "developer build"
 var filter string
-var testsExecuted = 0
+var testsExecuted atomic.Int32
 var validate *validator.Item
 
+// parallel is the maximum number of test files that can be executing at the same time. When
+// it is greater than one, subdirectories are run as independent branches, each with its own
+// copy of the dictionary.
+var parallel = 1
+
+// slots is used as a counting semaphore to limit the number of tests executing at once when
+// running in parallel.
+var slots chan struct{}
+
+// junitFile is the path of the JUnit XML report to write, if any. If junitBodies is true,
+// the request and response bodies are included in the report.
+var junitFile string
+var junitBodies bool
+
+// jsonReportFile is the path of the JSON results report to write, if any.
+var jsonReportFile string
+
+// allErrors and firstError are set by the --all-errors and --first-error options to control
+// if all the validations of a test are performed after one fails. When neither is set, all
+// validations are performed only when a report file is being written.
+var allErrors, firstError bool
+
+// setupFile and teardownFile are the reserved names of the test files in a directory that are
+// run before and after the other tests in the directory.
+const (
+	setupFile    = "setup.json"
+	teardownFile = "teardown.json"
+)
+
+// hookFailures is the number of setup and teardown tests that failed.
+var hookFailures atomic.Int32
+
+// failFast is set by the --fail-fast option, to skip the rest of the test run after the
+// first test that fails.
+var failFast bool
+
+// testsSkipped is the number of tests that were not run because of an earlier failure.
+var testsSkipped atomic.Int32
+
+// testsTimedOut is the number of tests that failed because a request did not complete in time.
+var testsTimedOut atomic.Int32
+
+// outputLock serializes the PASS/FAIL lines written by tests running in parallel.
+var outputLock sync.Mutex
+
 func main() {
 	var (
 		err            error
 		rootPath       string
 		pathList       []string
 		dictionaryList []string
+		dict           = dictionary.New()
 	)
 
 	now := time.Now()
@@ -46,19 +98,41 @@ func main() {
 
 	// Set up some default values for the dictionary. These can be overridden with the --define
 	// command line flag or placed in the dictionary.json file in the test directory.
-	dictionary.Dictionary["SCHEME"] = "https"
-	dictionary.Dictionary["HOST"] = hostname
-	dictionary.Dictionary["PASSWORD"] = "password" // Default testing password
-	dictionary.Dictionary["VERSION"] = BuildVersion
+	dict.Set("SCHEME", "https")
+	dict.Set("HOST", hostname)
+	dict.Set("PASSWORD", "password") // Default testing password
+	dict.Set("VERSION", BuildVersion)
 
 	// Scan over the command line arguments to set up the test environment.
 	for i := 1; i < len(os.Args); i++ {
 		arg := os.Args[i]
 		switch arg {
 		case "-h", "--help":
-			help()
+			help(dict)
 			os.Exit(0)
 
+		case "--all-errors":
+			allErrors = true
+
+		case "--first-error":
+			firstError = true
+
+		case "--fail-fast":
+			failFast = true
+
+		case "--deadline":
+			if i+1 >= len(os.Args) {
+				exit("missing argument for --deadline")
+			}
+
+			duration, err := time.ParseDuration(os.Args[i+1])
+			if err != nil || duration <= 0 {
+				exit("invalid duration for --deadline: " + os.Args[i+1])
+			}
+
+			tester.Deadline = now.Add(duration)
+			i++
+
 		case "-f", "--filter":
 			if i+1 >= len(os.Args) {
 				exit("missing argument for --filter")
@@ -67,6 +141,41 @@ func main() {
 			filter = os.Args[i+1]
 			i++
 
+		case "--no-reuse":
+			tester.NoReuse = true
+
+		case "-j", "--parallel":
+			if i+1 >= len(os.Args) {
+				exit("missing argument for --parallel")
+			}
+
+			count, err := strconv.Atoi(os.Args[i+1])
+			if err != nil || count < 1 {
+				exit("invalid count for --parallel: " + os.Args[i+1])
+			}
+
+			parallel = count
+			i++
+
+		case "--junit":
+			if i+1 >= len(os.Args) {
+				exit("missing argument for --junit")
+			}
+
+			junitFile = os.Args[i+1]
+			i++
+
+		case "--junit-bodies":
+			junitBodies = true
+
+		case "--report-json":
+			if i+1 >= len(os.Args) {
+				exit("missing argument for --report-json")
+			}
+
+			jsonReportFile = os.Args[i+1]
+			i++
+
 		case "-p", "--path":
 			if i+1 >= len(os.Args) {
 				exit("missing argument --path")
@@ -96,7 +205,35 @@ func main() {
 				exit("invalid key=value format for --define: " + os.Args[i+1])
 			}
 
-			dictionary.Dictionary[parts[0]] = parts[1]
+			dict.Set(parts[0], parts[1])
+
+			i++
+
+		case "--timeout":
+			if i+1 >= len(os.Args) {
+				exit("missing argument for --timeout")
+			}
+
+			duration, err := time.ParseDuration(os.Args[i+1])
+			if err != nil || duration < 0 {
+				exit("invalid duration for --timeout: " + os.Args[i+1])
+			}
+
+			tester.Timeout = duration
+			i++
+
+		case "--update-snapshots":
+			tester.UpdateSnapshots = true
+
+		case "--tags":
+			if i+1 >= len(os.Args) {
+				exit("missing argument for --tags")
+			}
+
+			tagExpression, err = tags.Parse(os.Args[i+1])
+			if err != nil {
+				exit("invalid --tags expression: " + err.Error())
+			}
 
 			i++
 
@@ -116,9 +253,15 @@ func main() {
 		exit("no path specified")
 	}
 
+	slots = make(chan struct{}, parallel)
+
+	// Unless explicitly set, report every failed validation of a test when the results
+	// are written to a report file.
+	tester.AllErrors = allErrors || (!firstError && (junitFile != "" || jsonReportFile != ""))
+
 	// Load all dictionaries referenced.
 	for _, path := range dictionaryList {
-		err := dictionary.Load(path)
+		err := dictionary.Load(dict, path)
 		if err != nil {
 			exit("bad dictionary path: " + err.Error())
 		}
@@ -131,7 +274,7 @@ func main() {
 			exit("bad test suite path: " + err.Error())
 		}
 
-		dictionary.Dictionary["ROOT"] = rootPath
+		dict.Set("ROOT", rootPath)
 
 		// if the path isn't a dictionary, just run the single test named.
 		info, err := os.Stat(rootPath)
@@ -139,21 +282,41 @@ func main() {
 			exit("bad test suite path: " + err.Error())
 		}
 
+		// If the rest of the run is being skipped, the tests in the path are reported as
+		// skipped without running them.
+		if reason := runSkipReason(); reason != "" {
+			if !info.IsDir() {
+				reportSkipped(filepath.Dir(rootPath), rootPath, reason)
+			} else {
+				_ = skipTests(path, reason, nil)
+			}
+
+			continue
+		}
+
 		if !info.IsDir() {
-			err = runSingleTest(rootPath)
+			err = runSingleTest(dict, rootPath)
 		} else {
 			// Run all the tests in the path
-			err = runTests(path)
+			err = runTests(dict, path, nil)
 		}
 
-		if err != nil {
-			if strings.Contains(err.Error(), defs.AbortError) {
-				fmt.Printf("Server testing unavailable, %v\n", err)
+		// A failure in one path does not stop the tests in the other paths, unless it
+		// aborted the run or fail-fast is set.
+		if isAbort(dict, err) {
+			fmt.Printf("Server testing unavailable, %v\n", err)
+		}
+	}
 
-				err = nil
-			}
+	if junitFile != "" {
+		if reportErr := report.WriteJUnit(junitFile, junitBodies); reportErr != nil {
+			exit("unable to write JUnit report: " + reportErr.Error())
+		}
+	}
 
-			break
+	if jsonReportFile != "" {
+		if reportErr := report.WriteJSON(jsonReportFile); reportErr != nil {
+			exit("unable to write JSON report: " + reportErr.Error())
 		}
 	}
 
@@ -163,7 +326,19 @@ func main() {
 	}
 
 	duration := time.Since(now)
-	fmt.Printf("\nExecuted %d tests in %v\n", testsExecuted, strings.TrimSpace(formats.Duration(duration, true)))
+	fmt.Printf("\nExecuted %d tests in %v\n", testsExecuted.Load(), strings.TrimSpace(formats.Duration(duration, true)))
+
+	if count := testsTimedOut.Load(); count > 0 {
+		fmt.Printf("%d tests timed out\n", count)
+	}
+
+	if count := testsSkipped.Load(); count > 0 {
+		fmt.Printf("Skipped %d tests\n", count)
+	}
+
+	if count := hookFailures.Load(); count > 0 {
+		fmt.Printf("%d setup or teardown tests failed\n", count)
+	}
 }
 
 func exit(msg string) {
@@ -171,8 +346,109 @@ func exit(msg string) {
 	os.Exit(1)
 }
 
-func runSingleTest(file string) error {
-	duration, err := TestFile(file)
+// isAbort determines if the error indicates that the server is unavailable, in which
+// case the remaining tests are not run. The text to look for can be overridden by the
+// "CONNECTION_REFUSED" dictionary value.
+func isAbort(dict *dictionary.Dictionary, err error) bool {
+	abortError := defs.AbortError
+
+	if text, ok := dict.Get("CONNECTION_REFUSED"); ok {
+		abortError = text
+	}
+
+	return err != nil && strings.Contains(err.Error(), abortError)
+}
+
+// isFailFast determines if the rest of the tests in a directory are skipped when one of
+// them fails. This is set with the "FAIL_FAST" dictionary value, typically in the
+// dictionary.json file of the directory.
+func isFailFast(dict *dictionary.Dictionary) bool {
+	text, _ := dict.Get("FAIL_FAST")
+	failFast, _ := strconv.ParseBool(text)
+
+	return failFast
+}
+
+// isSequential determines if the test files in a directory must be run in order even
+// when running in parallel, because they chain saved values from one test to the next.
+// This is set with the "SEQUENTIAL" dictionary value, typically in the dictionary.json
+// file of the directory.
+func isSequential(dict *dictionary.Dictionary) bool {
+	if parallel <= 1 {
+		return true
+	}
+
+	text, _ := dict.Get("SEQUENTIAL")
+	sequential, _ := strconv.ParseBool(text)
+
+	return sequential
+}
+
+func runSingleTest(dict *dictionary.Dictionary, file string) error {
+	return runFile(dict, filepath.Dir(file), filepath.Base(file), &skipState{})
+}
+
+// runFile runs a single test file from a test suite directory, waiting for an available
+// slot if tests are running in parallel. If the rest of the run or of the directory is being
+// skipped because of an earlier failure, the test is reported as skipped instead.
+func runFile(dict *dictionary.Dictionary, path, file string, directory *skipState) error {
+	if reason := skipReason(directory); reason != "" {
+		reportSkipped(path, filepath.Join(path, file), reason)
+
+		return nil
+	}
+
+	slots <- struct{}{}
+	defer func() { <-slots }()
+
+	// Check again, since an earlier failure may have happened while waiting for a slot.
+	if reason := skipReason(directory); reason != "" {
+		reportSkipped(path, filepath.Join(path, file), reason)
+
+		return nil
+	}
+
+	test, err := TestFile(dict, filepath.Join(path, file))
+	if isAbort(dict, err) {
+		runSkip.set("server unavailable")
+		reportSkipped(path, filepath.Join(path, file), "server unavailable")
+
+		return err
+	}
+
+	reportResult(path, filepath.Join(path, file), test, err)
+
+	if err != nil {
+		reason := filepath.Join(filepath.Base(path), file) + " failed"
+
+		if failFast || (test != nil && test.Abort) {
+			runSkip.set(reason)
+		}
+
+		if directory.failFast {
+			directory.set(reason)
+		}
+	}
+
+	return err
+}
+
+// reportResult prints the PASS or FAIL line for a test, counts it as executed, and
+// records the result for any report files.
+func reportResult(suite, path string, test *defs.Test, err error) {
+	var duration time.Duration
+
+	result := report.Result{Suite: suite, File: path, Test: test, Error: err}
+	report.Add(result)
+
+	if test != nil {
+		duration = test.Duration
+	}
+
+	file := filepath.Base(path)
+
+	outputLock.Lock()
+	defer outputLock.Unlock()
 
 	pad := ""
 
@@ -180,37 +456,196 @@ func runSingleTest(file string) error {
 		pad = "  "
 	}
 
-	if err != nil {
-		fmt.Printf("%sFAIL       %-40s: %v\n", pad, file, err)
+	// If the test was retried, show how many attempts were made.
+	attempts := ""
+	if test != nil && test.Attempts > 1 {
+		attempts = fmt.Sprintf(" (%d attempts)", test.Attempts)
+	}
+
+	if err != nil && test != nil && test.TimedOut {
+		fmt.Printf("%sTIMEOUT    %-40s: %v%s\n", pad, file, err, attempts)
+		testsTimedOut.Add(1)
+	} else if err != nil {
+		fmt.Printf("%sFAIL       %-40s: %v%s\n", pad, file, err, attempts)
 	} else {
-		fmt.Printf("%sPASS       %-40s %v\n", pad, file, formats.Duration(duration, true))
+		fmt.Printf("%sPASS       %-40s %v%s\n", pad, file, formats.Duration(duration, true), attempts)
 	}
 
-	testsExecuted++
+	// For a scenario, show the outcome of each of the steps under the scenario.
+	for index, status := range result.StepStatus() {
+		step := test.Steps[index]
+		name := fmt.Sprintf("%d. %s", index+1, step.Description)
 
-	return err
+		if status == "pass" {
+			fmt.Printf("%s  %-8s   %-38s %v\n", pad, strings.ToUpper(status), name, formats.Duration(step.Duration, true))
+		} else {
+			fmt.Printf("%s  %-8s   %s\n", pad, strings.ToUpper(status), name)
+		}
+	}
+
+	testsExecuted.Add(1)
 }
 
-func runTests(path string) error {
-	var (
-		duration time.Duration
-		lastErr  error
-	)
+// reportSkipped prints the SKIP line for a test that was not run, counts it as skipped, and
+// records the result for any report files.
+func reportSkipped(suite, path, reason string) {
+	report.Add(report.Result{Suite: suite, File: path, Skip: reason})
+
+	outputLock.Lock()
+	defer outputLock.Unlock()
+
+	pad := ""
+
+	if logging.Verbose {
+		pad = "  "
+	}
+
+	fmt.Printf("%sSKIP       %-40s: %s\n", pad, filepath.Base(path), reason)
+
+	testsSkipped.Add(1)
+}
+
+// reportHook prints the PASS or FAIL line for a setup or teardown test, and records the
+// result for any report files. These are not counted as tests executed.
+func reportHook(suite, path, phase string, test *defs.Test, err error) {
+	report.Add(report.Result{Suite: suite, File: path, Phase: phase, Test: test, Error: err})
+
+	outputLock.Lock()
+	defer outputLock.Unlock()
+
+	pad := ""
+
+	if logging.Verbose {
+		pad = "  "
+	}
+
+	label := strings.ToUpper(phase)
+
+	if err != nil {
+		hookFailures.Add(1)
+		fmt.Printf("%s%-10s %-40s: %v\n", pad, label+" FAIL", filepath.Join(filepath.Base(suite), filepath.Base(path)), err)
+	} else if logging.Verbose {
+		fmt.Printf("%s%-10s %-40s %v\n", pad, label, filepath.Join(filepath.Base(suite), filepath.Base(path)), formats.Duration(test.Duration, true))
+	}
+}
+
+// reportHookSkipped prints the SKIP line for a setup or teardown test that was not run, and
+// records the result for any report files. These are not counted as tests skipped.
+func reportHookSkipped(suite, path, phase, reason string) {
+	report.Add(report.Result{Suite: suite, File: path, Phase: phase, Skip: reason})
+
+	outputLock.Lock()
+	defer outputLock.Unlock()
+
+	pad := ""
 
+	if logging.Verbose {
+		pad = "  "
+	}
+
+	label := strings.ToUpper(phase)
+
+	fmt.Printf("%s%-10s %-40s: %s\n", pad, label+" SKIP", filepath.Join(filepath.Base(suite), filepath.Base(path)), reason)
+}
+
+// runTests runs the tests in a test suite directory and its subdirectories. The parent list
+// is the default tags of the parent directory.
+func runTests(dict *dictionary.Dictionary, path string, parent []string) error {
 	if logging.Verbose {
 		fmt.Printf("Testing suite %s...\n", path)
 	}
 
 	// First, try to load any dictionary in the path location. If not found, we don't care.
-	err := dictionary.Load(filepath.Join(path, "dictionary.json"))
+	err := dictionary.Load(dict, filepath.Join(path, "dictionary.json"))
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
 
-	// If the dictionary included in a different abort error string, update the one we
-	// test against now.
-	if text, ok := dictionary.Dictionary["CONNECTION_REFUSED"]; ok {
-		defs.AbortError = text
+	// If none of the tests in the directory are selected, there is nothing to do, not even
+	// the setup and teardown.
+	defaults := directoryTags(path, parent)
+	if !hasSelectedTests(path, defaults) {
+		return nil
+	}
+
+	// If the rest of the run is being skipped, report all the tests in the directory as
+	// skipped without running the setup or teardown.
+	if reason := runSkipReason(); reason != "" {
+		return skipTests(path, reason, parent)
+	}
+
+	// Run the setup test of the directory, if any. If it fails, the tests in the directory
+	// are reported as skipped.
+	err = runHook(dict, path, setupFile, report.Setup)
+	if err == nil {
+		err = runDirectory(dict, path, defaults)
+	} else if isAbort(dict, err) {
+		_ = skipTests(path, runSkipReason(), parent)
+	} else {
+		_ = skipTests(path, "setup failed", parent)
+	}
+
+	// The teardown test of the directory is always run, unless the server is unavailable or
+	// the deadline of the run has passed. A teardown failure is only returned if the tests
+	// themselves did not fail.
+	if !isAbort(dict, err) {
+		if teardownErr := runHook(dict, path, teardownFile, report.Teardown); err == nil {
+			err = teardownErr
+		}
+	}
+
+	return err
+}
+
+// runHook runs the reserved setup or teardown test file of a directory, if there is one. The
+// file can be a single test or a scenario, and runs using the dictionary of the directory so
+// values it saves are available to the tests in the directory.
+func runHook(dict *dictionary.Dictionary, path, name, phase string) error {
+	file := filepath.Join(path, name)
+
+	if _, err := os.Stat(file); err != nil {
+		return nil
+	}
+
+	// Once the deadline of the run has passed, the hook is reported as skipped, the same as
+	// the tests. Its request would only be stopped at the deadline.
+	if deadlinePassed() {
+		reportHookSkipped(path, file, phase, runSkipReason())
+
+		return nil
+	}
+
+	test, err := TestFile(dict, file)
+	if isAbort(dict, err) {
+		runSkip.set("server unavailable")
+
+		return err
+	}
+
+	reportHook(path, file, phase, test, err)
+
+	if err != nil {
+		return fmt.Errorf("%s failed, %v", phase, err)
+	}
+
+	return nil
+}
+
+// runDirectory runs the test files and subdirectories of a test suite directory, using the
+// default tags of the directory to select the tests.
+func runDirectory(dict *dictionary.Dictionary, path string, defaults []string) error {
+	var (
+		lastErr error
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+	)
+
+	// setError records an error from a test or branch that may be running in parallel.
+	setError := func(err error) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		lastErr = err
 	}
 
 	// Read the contents of the tests directory.
@@ -219,38 +654,49 @@ func runTests(path string) error {
 		return err
 	}
 
+	// This records the reason the rest of the directory is skipped, when a test fails and
+	// the directory is set to fail fast. The setting is read before any subdirectory can
+	// load a dictionary that changes it.
+	directory := &skipState{failFast: isFailFast(dict)}
+
 	fileNames := make([]string, 0)
 
 	for _, file := range files {
 		if file.IsDir() {
 			subdir := filepath.Join(path, file.Name())
 
+			// When running in parallel, the subdirectory is an independent branch of the
+			// test run with its own copy of the dictionary.
+			if parallel > 1 {
+				branch := dict.Clone()
+
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					if err := runSubdirectory(branch, subdir, directory, defaults); err != nil {
+						setError(err)
+					}
+				}()
+
+				continue
+			}
+
 			// Recursively run the tests in the subdirectory.
-			err = runTests(subdir)
-			if err != nil {
-				return err
+			if err := runSubdirectory(dict, subdir, directory, defaults); err != nil {
+				setError(err)
 			}
 
 			continue
 		}
 
-		// If it's the reserved name "dictionary.json", skip it.
+		// Skip the reserved files and files that are not JSON.
 		name := file.Name()
-		if name == "dictionary.json" {
+		if !isTestFile(name) {
 			continue
 		}
 
-		// If it's not a JSON file, skip it.
-		if filepath.Ext(name) != ".json" {
-			continue
-		}
-
-		if filter != "" {
-			if !strings.Contains(name, filter) {
-				continue
-			}
-		}
-
 		fileNames = append(fileNames, name)
 	}
 
@@ -258,33 +704,75 @@ func runTests(path string) error {
 	// are run in a consistent order.
 	sort.Strings(fileNames)
 
-	// For each test file, run the tests.
-	for _, file := range fileNames {
-		name := filepath.Join(path, file)
+	// If any of the tests depend on other tests, they are run in the order of their
+	// dependencies rather than in alphabetical order.
+	graph, err := readGraph(path, fileNames, defaults)
+	if err != nil {
+		return err
+	}
 
-		duration, err = TestFile(name)
-		if err != nil && strings.Contains(err.Error(), defs.AbortError) {
-			break
+	if graph != nil {
+		if err := runGraph(dict, path, graph, directory); err != nil {
+			setError(err)
 		}
 
-		if err != nil {
-			lastErr = err
-		}
+		wg.Wait()
 
-		pad := ""
+		return lastErr
+	}
 
-		if logging.Verbose {
-			pad = "  "
-		}
+	// Otherwise, run the files selected by the filter and tags.
+	selected := fileNames[:0]
 
-		if err != nil {
-			fmt.Printf("%sFAIL       %-40s: %v\n", pad, file, err)
-		} else {
-			fmt.Printf("%sPASS       %-40s %v\n", pad, file, formats.Duration(duration, true))
+	for _, name := range fileNames {
+		if isSelected(path, name, defaults) {
+			selected = append(selected, name)
 		}
+	}
+
+	fileNames = selected
 
-		testsExecuted++
+	if isSequential(dict) {
+		// For each test file, run the tests in order.
+		for _, file := range fileNames {
+			if err := runFile(dict, path, file, directory); err != nil {
+				setError(err)
+			}
+		}
+	} else {
+		// Each test file runs with its own copy of the dictionary, since there is no
+		// predictable order in which tests complete to pass saved values between them.
+		for _, file := range fileNames {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if err := runFile(dict.Clone(), path, file, directory); err != nil {
+					setError(err)
+				}
+			}()
+		}
 	}
 
+	wg.Wait()
+
 	return lastErr
 }
+
+// runSubdirectory runs the tests in a subdirectory of a test suite directory. If the rest of
+// the parent directory is being skipped because of an earlier failure, the tests in the
+// subdirectory are reported as skipped instead. The parent list is the default tags of the
+// parent directory.
+func runSubdirectory(dict *dictionary.Dictionary, subdir string, directory *skipState, parent []string) error {
+	if reason := directory.get(); reason != "" {
+		return skipTests(subdir, reason, parent)
+	}
+
+	err := runTests(dict, subdir, parent)
+	if err != nil && directory.failFast {
+		directory.set(filepath.Base(subdir) + " failed")
+	}
+
+	return err
+}
@@ -0,0 +1,274 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// The comparison operators allowed in a filter expression. The two-character operators
+// are listed first so they are found before the single-character ones.
+var filterOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// evaluateFilter determines if an element of an array matches a filter expression, such as
+// the "@.name=='Alice'" in the query "users[?(@.name=='Alice')]". In the expression, "@" is
+// the element being tested, and "@.name" is a query relative to the element. A term can
+// compare a query to a JSON value (a string may also use single quotes) using one of the
+// operators ==, !=, <, <=, >, or >=. A term without an operator is true if the query finds
+// a value that is not false or null. Terms can be combined with && and ||, where && is
+// evaluated first.
+func evaluateFilter(element interface{}, expression string) (bool, error) {
+	for _, alternative := range splitOutside(expression, "||") {
+		matched := true
+
+		for _, term := range splitOutside(alternative, "&&") {
+			ok, err := evaluateTerm(element, term)
+			if err != nil {
+				return false, err
+			}
+
+			if !ok {
+				matched = false
+
+				break
+			}
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// evaluateTerm evaluates a single comparison in a filter expression.
+func evaluateTerm(element interface{}, term string) (bool, error) {
+	term = strings.TrimSpace(term)
+
+	// A term in parentheses can itself be a filter expression.
+	if enclosed(term) {
+		return evaluateFilter(element, term[1:len(term)-1])
+	}
+
+	if term == "" {
+		return false, fmt.Errorf("Empty filter expression")
+	}
+
+	position, operator := findOperator(term)
+	if operator == "" {
+		value, found, err := filterOperand(element, term)
+		if err != nil || !found {
+			return false, err
+		}
+
+		return value != nil && value != false, nil
+	}
+
+	left, leftFound, err := filterOperand(element, term[:position])
+	if err != nil {
+		return false, err
+	}
+
+	right, rightFound, err := filterOperand(element, term[position+len(operator):])
+	if err != nil {
+		return false, err
+	}
+
+	if !leftFound || !rightFound {
+		return false, nil
+	}
+
+	switch operator {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	}
+
+	// The ordering operators can compare two numbers or two strings.
+	var order int
+
+	if l, ok := left.(float64); ok {
+		r, ok := right.(float64)
+		if !ok {
+			return false, nil
+		}
+
+		switch {
+		case l < r:
+			order = -1
+		case l > r:
+			order = 1
+		}
+	} else if l, ok := left.(string); ok {
+		r, ok := right.(string)
+		if !ok {
+			return false, nil
+		}
+
+		order = strings.Compare(l, r)
+	} else {
+		return false, nil
+	}
+
+	switch operator {
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	default:
+		return order >= 0, nil
+	}
+}
+
+// filterOperand returns the value of one side of a comparison in a filter expression. This
+// is either a query relative to the element starting with "@", or a JSON value. The flag is
+// false if the query does not find a value.
+func filterOperand(element interface{}, text string) (interface{}, bool, error) {
+	var value interface{}
+
+	text = strings.TrimSpace(text)
+
+	if strings.HasPrefix(text, "@") {
+		query := strings.TrimPrefix(strings.TrimPrefix(text, "@"), ".")
+		if query == "" {
+			return element, true, nil
+		}
+
+		values, err := parse(element, query)
+		if err != nil || len(values) == 0 {
+			return nil, false, nil
+		}
+
+		return values[0], true, nil
+	}
+
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+		return strings.ReplaceAll(text[1:len(text)-1], "\\'", "'"), true, nil
+	}
+
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return nil, false, fmt.Errorf("Invalid filter value: %s", text)
+	}
+
+	return value, true, nil
+}
+
+// findOperator finds the first comparison operator in the term that is not inside a quoted
+// string, returning its position and text. The text is empty if there is no operator.
+func findOperator(term string) (int, string) {
+	var quote byte
+
+	for i := 0; i < len(term); i++ {
+		ch := term[i]
+
+		if quote != 0 {
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+
+			continue
+		}
+
+		if ch == '\'' || ch == '"' {
+			quote = ch
+
+			continue
+		}
+
+		for _, operator := range filterOperators {
+			if strings.HasPrefix(term[i:], operator) {
+				return i, operator
+			}
+		}
+	}
+
+	return 0, ""
+}
+
+// enclosed determines if the text starts with an open parenthesis that is closed by the
+// parenthesis at the end of the text.
+func enclosed(text string) bool {
+	var quote byte
+
+	if !strings.HasPrefix(text, "(") || !strings.HasSuffix(text, ")") {
+		return false
+	}
+
+	depth := 0
+
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+
+		case ch == '\'' || ch == '"':
+			quote = ch
+
+		case ch == '(':
+			depth++
+
+		case ch == ')':
+			depth--
+			if depth == 0 {
+				return i == len(text)-1
+			}
+		}
+	}
+
+	return false
+}
+
+// splitOutside splits the text at each occurrence of the separator that is not inside a
+// quoted string or parentheses.
+func splitOutside(text, separator string) []string {
+	var (
+		parts []string
+		quote byte
+	)
+
+	depth := 0
+	start := 0
+
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+
+		case ch == '\'' || ch == '"':
+			quote = ch
+
+		case ch == '(':
+			depth++
+
+		case ch == ')':
+			depth--
+
+		case depth == 0 && strings.HasPrefix(text[i:], separator):
+			parts = append(parts, text[start:i])
+			i += len(separator) - 1
+			start = i + 1
+		}
+	}
+
+	return append(parts, text[start:])
+}
@@ -0,0 +1,232 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tucats/apitest/data"
+)
+
+// queryFunction computes a value from the values found by a query. The multiple flag is true
+// if the query can select more than one item, such as with a wildcard or filter. The error is
+// the error from the query, if any.
+type queryFunction func(values []interface{}, multiple bool, err error) ([]interface{}, error)
+
+// functions are the functions that can be applied to the result of a query, as in the
+// query "count(items)".
+var functions = map[string]queryFunction{
+	"count":  countFunction,
+	"keys":   keysFunction,
+	"length": lengthFunction,
+	"len":    lengthFunction,
+	"sum":    sumFunction,
+	"min":    minFunction,
+	"max":    maxFunction,
+}
+
+var functionPattern = regexp.MustCompile(`^\s*([a-zA-Z]+)\((.*)\)\s*$`)
+
+// query evaluates the item against the body. The item is either a query, or the name of a
+// function followed by a query (which may itself use a function) in parentheses.
+func query(body interface{}, item string) ([]interface{}, error) {
+	parts := functionPattern.FindStringSubmatch(item)
+	if parts == nil {
+		return parse(body, item)
+	}
+
+	fn, found := functions[strings.ToLower(parts[1])]
+	if !found {
+		return parse(body, item)
+	}
+
+	inner := strings.TrimSpace(parts[2])
+	if inner == "" {
+		inner = "."
+	}
+
+	values, err := query(body, inner)
+
+	return fn(values, selectsMultiple(inner), err)
+}
+
+// selectsMultiple determines if a query can select more than one item. This is true for a
+// query that uses a function, or that has a segment such as a wildcard or filter.
+func selectsMultiple(item string) bool {
+	if item == "." {
+		return false
+	}
+
+	if functionPattern.MatchString(item) {
+		return true
+	}
+
+	segments, err := tokenize(item)
+	if err != nil {
+		return false
+	}
+
+	for _, s := range segments {
+		if s.multiple() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// countFunction returns the number of items found by the query. If the query names a single
+// array, this is the number of elements, and if it names a single object this is the number
+// of keys. A query that finds nothing has a count of zero.
+func countFunction(values []interface{}, multiple bool, err error) ([]interface{}, error) {
+	if err != nil {
+		if errors.As(err, &notFoundError{}) {
+			return []interface{}{0.0}, nil
+		}
+
+		return nil, err
+	}
+
+	return []interface{}{float64(len(elements(values, multiple)))}, nil
+}
+
+// keysFunction returns the keys of the object found by the query, in sorted order.
+func keysFunction(values []interface{}, _ bool, err error) ([]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) != 1 || !isMap(values[0]) {
+		return nil, fmt.Errorf("keys() requires a single object")
+	}
+
+	result := []interface{}{}
+	for _, key := range mapKeys(values[0]) {
+		result = append(result, key)
+	}
+
+	return result, nil
+}
+
+// lengthFunction returns the length of each value found by the query. For a string this is
+// the number of characters, for an array the number of elements, and for an object the
+// number of keys.
+func lengthFunction(values []interface{}, _ bool, err error) ([]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, 0, len(values))
+
+	for _, value := range values {
+		switch actual := value.(type) {
+		case string:
+			result = append(result, float64(utf8.RuneCountInString(actual)))
+
+		default:
+			if list, ok := arrayElements(value); ok {
+				result = append(result, float64(len(list)))
+			} else if isMap(value) {
+				result = append(result, float64(len(mapKeys(value))))
+			} else {
+				return nil, fmt.Errorf("length() requires a string, array, or object: %s", TypeName(value))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// sumFunction returns the sum of the numeric values found by the query.
+func sumFunction(values []interface{}, multiple bool, err error) ([]interface{}, error) {
+	numbers, err := numericValues("sum", values, multiple, err)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0.0
+	for _, n := range numbers {
+		total += n
+	}
+
+	return []interface{}{total}, nil
+}
+
+// minFunction returns the smallest of the numeric values found by the query.
+func minFunction(values []interface{}, multiple bool, err error) ([]interface{}, error) {
+	numbers, err := numericValues("min", values, multiple, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("min() requires at least one value")
+	}
+
+	result := math.Inf(1)
+	for _, n := range numbers {
+		result = math.Min(result, n)
+	}
+
+	return []interface{}{result}, nil
+}
+
+// maxFunction returns the largest of the numeric values found by the query.
+func maxFunction(values []interface{}, multiple bool, err error) ([]interface{}, error) {
+	numbers, err := numericValues("max", values, multiple, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("max() requires at least one value")
+	}
+
+	result := math.Inf(-1)
+	for _, n := range numbers {
+		result = math.Max(result, n)
+	}
+
+	return []interface{}{result}, nil
+}
+
+// numericValues converts the values found by a query to numbers for the named function.
+func numericValues(name string, values []interface{}, multiple bool, err error) ([]float64, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	list := elements(values, multiple)
+	numbers := make([]float64, 0, len(list))
+
+	for _, value := range list {
+		n, err := data.Float64(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s() requires numeric values: %v", name, value)
+		}
+
+		numbers = append(numbers, n)
+	}
+
+	return numbers, nil
+}
+
+// elements returns the items that a function applies to. If the query names a single array
+// or object, these are its elements or values. Otherwise, they are the values found, even if
+// a query that can select several items found only one.
+func elements(values []interface{}, multiple bool) []interface{} {
+	if !multiple && len(values) == 1 {
+		if list, ok := arrayElements(values[0]); ok {
+			return list
+		}
+
+		if isMap(values[0]) {
+			return mapValues(values[0])
+		}
+	}
+
+	return values
+}
@@ -26,6 +26,18 @@ func GetOneItem(text string, item string) (string, error) {
 // is a dot-notation string that can include integer indices and string map key values. The value is
 // always returned as a string representation.
 func GetItem(text string, item string) ([]string, error) {
+	values, err := GetValues(text, item)
+	if err != nil {
+		return nil, err
+	}
+
+	return Strings(values), nil
+}
+
+// GetValues extracts a specific item from the JSON payload string, the same as GetItem. The values
+// are returned with the types created when the JSON is unmarshalled: string, float64, bool, nil,
+// []interface{} for an array, or map[string]interface{} for an object.
+func GetValues(text string, item string) ([]interface{}, error) {
 	// Convert the body text to an arbitrary interface object using JSON
 	var body interface{}
 
@@ -33,5 +45,16 @@ func GetItem(text string, item string) ([]string, error) {
 		return nil, err
 	}
 
-	return parse(body, item)
+	return query(body, item)
+}
+
+// Strings converts a list of values returned by GetValues to their string representations.
+func Strings(values []interface{}) []string {
+	result := make([]string, len(values))
+
+	for i, value := range values {
+		result[i] = fmt.Sprintf("%v", value)
+	}
+
+	return result
 }
@@ -1,6 +1,9 @@
 package parser
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestGetItem(t *testing.T) {
 	tests := []struct {
@@ -99,3 +102,356 @@ func TestGetItem(t *testing.T) {
 		})
 	}
 }
+
+func TestGetValuesTypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		item     string
+		wantType string
+		wantText string
+	}{
+		{
+			name:     "string value",
+			text:     `{ "count": "5" }`,
+			item:     "count",
+			wantType: "string",
+			wantText: "5",
+		},
+		{
+			name:     "number value",
+			text:     `{ "count": 5 }`,
+			item:     "count",
+			wantType: "number",
+			wantText: "5",
+		},
+		{
+			name:     "null value",
+			text:     `{ "extra": null }`,
+			item:     "extra",
+			wantType: "null",
+			wantText: "<nil>",
+		},
+		{
+			name:     "boolean value",
+			text:     `{ "open": false }`,
+			item:     "open",
+			wantType: "boolean",
+			wantText: "false",
+		},
+		{
+			name:     "object value",
+			text:     `{ "person": { "age": 43 } }`,
+			item:     "person",
+			wantType: "object",
+			wantText: "map[age:43]",
+		},
+		{
+			name:     "array value",
+			text:     `{ "list": [ 1, 2 ] }`,
+			item:     "list",
+			wantType: "array",
+			wantText: "[1 2]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetValues(tt.text, tt.item)
+			if err != nil {
+				t.Errorf("GetValues() error = %v", err)
+
+				return
+			}
+
+			if len(got) != 1 {
+				t.Errorf("GetValues() returned %d values, want 1", len(got))
+
+				return
+			}
+
+			if kind := TypeName(got[0]); kind != tt.wantType {
+				t.Errorf("TypeName() = %v, want %v", kind, tt.wantType)
+			}
+
+			if text := Strings(got)[0]; text != tt.wantText {
+				t.Errorf("Strings() = %v, want %v", text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestIsType(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		kind  string
+		want  bool
+	}{
+		{name: "integer number", value: 42.0, kind: "integer", want: true},
+		{name: "fractional number", value: 42.5, kind: "integer", want: false},
+		{name: "fractional number is number", value: 42.5, kind: "number", want: true},
+		{name: "string is not number", value: "42", kind: "number", want: false},
+		{name: "bool synonym", value: true, kind: "bool", want: true},
+		{name: "null", value: nil, kind: "null", want: true},
+		{name: "object synonym", value: map[string]interface{}{}, kind: "map", want: true},
+		{name: "array", value: []interface{}{}, kind: "Array", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsType(tt.value, tt.kind); got != tt.want {
+				t.Errorf("IsType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+		want     bool
+	}{
+		{name: "number equals number", value: 5.0, expected: `5`, want: true},
+		{name: "string does not equal number", value: "5", expected: `5`, want: false},
+		{name: "quoted string equals string", value: "5", expected: `"5"`, want: true},
+		{name: "null equals null", value: nil, expected: `null`, want: true},
+		{name: "string does not equal null", value: "<nil>", expected: `null`, want: false},
+		{name: "unquoted text is a string", value: "brown", expected: `brown`, want: true},
+		{name: "object equals object", value: map[string]interface{}{"a": 1.0}, expected: `{"a": 1}`, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.value, tt.expected); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetItemQueries(t *testing.T) {
+	users := `{ "users": [
+		{ "name": "Alice", "id": 1, "age": 30, "tags": ["admin", "dev"] },
+		{ "name": "Bob", "id": 2, "age": 25, "tags": ["dev"] },
+		{ "name": "Carol", "id": 3, "age": 41, "manager": { "id": 1 } }
+	] }`
+
+	tests := []struct {
+		name    string
+		text    string
+		item    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "wildcard",
+			text: users,
+			item: "users.*.name",
+			want: []string{"Alice", "Bob", "Carol"},
+		},
+		{
+			name: "wildcard skips missing items",
+			text: users,
+			item: "users.*.manager.id",
+			want: []string{"1"},
+		},
+		{
+			name: "bracket wildcard",
+			text: users,
+			item: "users[*].id",
+			want: []string{"1", "2", "3"},
+		},
+		{
+			name: "bracket index",
+			text: users,
+			item: "users[1].name",
+			want: []string{"Bob"},
+		},
+		{
+			name: "negative index",
+			text: users,
+			item: "users.-1.name",
+			want: []string{"Carol"},
+		},
+		{
+			name: "bracket negative index",
+			text: users,
+			item: "users[-2].name",
+			want: []string{"Bob"},
+		},
+		{
+			name:    "negative index out of range",
+			text:    users,
+			item:    "users[-4].name",
+			wantErr: true,
+		},
+		{
+			name: "slice",
+			text: users,
+			item: "users[0:2].name",
+			want: []string{"Alice", "Bob"},
+		},
+		{
+			name: "dot slice with open end",
+			text: users,
+			item: "users.1:.name",
+			want: []string{"Bob", "Carol"},
+		},
+		{
+			name: "slice with negative start",
+			text: users,
+			item: "users[-1:].name",
+			want: []string{"Carol"},
+		},
+		{
+			name: "filter on string",
+			text: users,
+			item: `users[?(@.name=="Alice")].id`,
+			want: []string{"1"},
+		},
+		{
+			name: "filter with single quotes",
+			text: users,
+			item: `users[?(@.name == 'Bob')].age`,
+			want: []string{"25"},
+		},
+		{
+			name: "filter on number",
+			text: users,
+			item: `users[?(@.age > 28)].name`,
+			want: []string{"Alice", "Carol"},
+		},
+		{
+			name: "filter with and",
+			text: users,
+			item: `users[?(@.age > 28 && @.id != 1)].name`,
+			want: []string{"Carol"},
+		},
+		{
+			name: "filter with grouped or",
+			text: users,
+			item: `users[?((@.id == 1 || @.id == 2) && @.age < 30)].name`,
+			want: []string{"Bob"},
+		},
+		{
+			name: "filter on existence",
+			text: users,
+			item: `users[?(@.manager)].name`,
+			want: []string{"Carol"},
+		},
+		{
+			name: "filter on array element",
+			text: users,
+			item: `users[?(@.tags[0] == "admin")].name`,
+			want: []string{"Alice"},
+		},
+		{
+			name: "filter on scalar element",
+			text: `{ "list": [ 1, 5, 10 ] }`,
+			item: `list[?(@ >= 5)]`,
+			want: []string{"5", "10"},
+		},
+		{
+			name:    "filter with no match",
+			text:    users,
+			item:    `users[?(@.name=="Dave")].id`,
+			wantErr: true,
+		},
+		{
+			name: "recursive descent",
+			text: users,
+			item: "..id",
+			want: []string{"1", "2", "3", "1"},
+		},
+		{
+			name: "recursive descent below a key",
+			text: `{ "a": { "b": { "id": 7 } }, "id": 3 }`,
+			item: "a..id",
+			want: []string{"7"},
+		},
+		{
+			name: "bracket quoted key with dot",
+			text: `{ "user.name": "Alice" }`,
+			item: `['user.name']`,
+			want: []string{"Alice"},
+		},
+		{
+			name: "numeric map key",
+			text: `{ "200": { "count": 4 } }`,
+			item: `200.count`,
+			want: []string{"4"},
+		},
+		{
+			name:    "missing closing bracket",
+			text:    users,
+			item:    "users[0.name",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetItem(tt.text, tt.item)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetItem() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if !tt.wantErr && strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("GetItem() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetItemFunctions(t *testing.T) {
+	text := `{ "meta": { "b": 2, "a": 1 }, "name": "Zoë", "empty": [],
+		"items": [ { "price": 10.5 }, { "price": 3 }, { "price": 7 } ],
+		"users": [ { "name": "Ann", "role": "admin" }, { "name": "Bob", "role": "user" } ] }`
+
+	tests := []struct {
+		name    string
+		item    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "count array", item: "count(items)", want: []string{"3"}},
+		{name: "count object", item: "count(meta)", want: []string{"2"}},
+		{name: "count wildcard", item: "count(items.*.price)", want: []string{"3"}},
+		{name: "count empty array", item: "count(empty)", want: []string{"0"}},
+		{name: "count no match", item: "count(items[?(@.price > 100)])", want: []string{"0"}},
+		{name: "count filter with one match", item: `count(users[?(@.role=="admin")])`, want: []string{"1"}},
+		{name: "count slice with one match", item: "count(users[0:1])", want: []string{"1"}},
+		{name: "count wildcard with one match", item: "count(users[?(@.role=='user')].*)", want: []string{"2"}},
+		{name: "count missing item", item: "count(missing)", want: []string{"0"}},
+		{name: "count missing index", item: "count(users[5])", want: []string{"0"}},
+		{name: "count invalid query", item: "count(users[0.name)", wantErr: true},
+		{name: "count path through a string", item: "count(name.first)", wantErr: true},
+		{name: "count invalid filter", item: "count(users[?()])", wantErr: true},
+		{name: "keys", item: "keys(meta)", want: []string{"a", "b"}},
+		{name: "count keys", item: "count(keys(meta))", want: []string{"2"}},
+		{name: "length of string", item: "length(name)", want: []string{"3"}},
+		{name: "length of array", item: "len(items)", want: []string{"3"}},
+		{name: "sum", item: "sum(items.*.price)", want: []string{"20.5"}},
+		{name: "sum of array", item: "sum(items[*].price)", want: []string{"20.5"}},
+		{name: "min", item: "min(items.*.price)", want: []string{"3"}},
+		{name: "max", item: "max(items.*.price)", want: []string{"10.5"}},
+		{name: "max of non-numeric", item: "max(name)", wantErr: true},
+		{name: "keys of array", item: "keys(items)", wantErr: true},
+		{name: "function of missing item", item: "sum(prices)", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetItem(text, tt.item)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetItem() error = %v, wantErr %v", err, tt.wantErr)
+
+				return
+			}
+
+			if !tt.wantErr && strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("GetItem() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
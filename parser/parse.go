@@ -3,78 +3,267 @@ package parser
 import (
 	"fmt"
 	"reflect"
-	"strconv"
-	"strings"
+	"sort"
 )
 
-func parse(body interface{}, item string) ([]string, error) {
-	var (
-		index   int
-		isIndex bool
-		name    string
-	)
+// notFoundError is the error for a query that names an item the body does not have, as
+// opposed to a query that is not valid or does not fit the structure of the body.
+type notFoundError struct {
+	message string
+}
+
+func (e notFoundError) Error() string {
+	return e.message
+}
 
+// parse evaluates the query in item against the body, which is the result of unmarshalling
+// JSON text. Each segment of the query is applied in turn to the items found so far. Until
+// a segment that can select several items (such as "*") is applied, an error applying a
+// segment is an error for the query. After that, items for which the rest of the query
+// cannot be applied are skipped, and it is an error only if no items are found.
+func parse(body interface{}, item string) ([]interface{}, error) {
 	// If the item is just a "dot" it means the entire body is the result
 	if item == "." {
-		return []string{fmt.Sprintf("%v", body)}, nil
+		return []interface{}{body}, nil
 	}
 
-	item = dotQuote(item)
+	segments, err := tokenize(item)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []interface{}{body}
+	multiple := false
+
+	for _, s := range segments {
+		var next []interface{}
+
+		for _, value := range values {
+			found, err := s.apply(value)
+			if err != nil {
+				if !multiple {
+					return nil, err
+				}
+
+				continue
+			}
+
+			next = append(next, found...)
+		}
 
-	// Split out the item we seek plus whatever might be after it
-	parts := strings.SplitN(item, ".", 2)
-	if len(parts) == 1 {
-		parts = append(parts, ".")
+		multiple = multiple || s.multiple()
+		values = next
 	}
 
-	for i, part := range parts {
-		if i == 0 {
-			parts[i] = dotUnquote(part, ".")
-		} else {
-			parts[i] = dotUnquote(part, "\\.")
+	if multiple && len(values) == 0 {
+		return nil, notFoundError{fmt.Sprintf("Array element not found: %v", item)}
+	}
+
+	return values, nil
+}
+
+// apply returns the items selected by the segment from the given value.
+func (s segment) apply(value interface{}) ([]interface{}, error) {
+	switch s.kind {
+	case nameSegment:
+		return mapElement(value, s.text)
+
+	case indexSegment:
+		list, ok := arrayElements(value)
+		if !ok {
+			// A numeric key is permitted in a map.
+			if isMap(value) {
+				return mapElement(value, s.text)
+			}
+
+			return nil, fmt.Errorf("Item is not an array: %T", value)
+		}
+
+		index := s.index
+		if index < 0 {
+			index += len(list)
+		}
+
+		if index < 0 || index >= len(list) {
+			return nil, notFoundError{fmt.Sprintf("Index out of range: %d", s.index)}
+		}
+
+		return []interface{}{list[index]}, nil
+
+	case sliceSegment:
+		list, ok := arrayElements(value)
+		if !ok {
+			if isMap(value) {
+				return mapElement(value, s.text)
+			}
+
+			return nil, fmt.Errorf("Item is not an array: %T", value)
+		}
+
+		start, end := 0, len(list)
+
+		if s.hasStart {
+			start = sliceBound(s.start, len(list))
+		}
+
+		if s.hasEnd {
+			end = sliceBound(s.end, len(list))
+		}
+
+		if start >= end {
+			return []interface{}{}, nil
+		}
+
+		return list[start:end], nil
+
+	case wildcardSegment:
+		if list, ok := arrayElements(value); ok {
+			return list, nil
+		}
+
+		if isMap(value) {
+			return mapValues(value), nil
+		}
+
+		return nil, fmt.Errorf("Item is not an array: %T", value)
+
+	case filterSegment:
+		list, ok := arrayElements(value)
+		if !ok {
+			if !isMap(value) {
+				return nil, fmt.Errorf("Item is not an array: %T", value)
+			}
+
+			list = mapValues(value)
 		}
+
+		result := []interface{}{}
+
+		for _, element := range list {
+			match, err := evaluateFilter(element, s.text)
+			if err != nil {
+				return nil, err
+			}
+
+			if match {
+				result = append(result, element)
+			}
+		}
+
+		return result, nil
+
+	case recursiveSegment:
+		return descendants(value, s.text, nil), nil
 	}
 
-	// Determine if this is a numeric index or a name
-	if i, err := strconv.Atoi(parts[0]); err == nil {
-		index = i
-		isIndex = true
-	} else {
-		name = parts[0]
+	return nil, fmt.Errorf("Item is not a map, item, or array: %T", value)
+}
+
+// sliceBound converts a slice start or end position to an index in an array of the given
+// length. A negative position counts from the end of the array.
+func sliceBound(position, length int) int {
+	if position < 0 {
+		position += length
+	}
+
+	if position < 0 {
+		return 0
+	}
+
+	if position > length {
+		return length
 	}
 
-	// Is the name the wildcard array index?
-	if name == "*" {
-		return anyArrayElement(body, parts, item)
+	return position
+}
+
+// arrayElements returns the elements of the value if it is an array.
+func arrayElements(value interface{}) ([]interface{}, bool) {
+	if list, ok := value.([]interface{}); ok {
+		return list, true
 	}
 
-	// If it's an index, the current item must be an array
-	if isIndex {
-		return arrayElement(body, index, parts, item)
+	val := reflect.ValueOf(value)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, false
 	}
 
-	// If it's a name, the current item must be a map of some type.
-	val := reflect.ValueOf(body)
+	list := make([]interface{}, val.Len())
+	for i := range list {
+		list[i] = val.Index(i).Interface()
+	}
+
+	return list, true
+}
+
+func isMap(value interface{}) bool {
+	return reflect.ValueOf(value).Kind() == reflect.Map
+}
+
+// mapElement returns the value of the named key if the value is a map.
+func mapElement(value interface{}, name string) ([]interface{}, error) {
+	val := reflect.ValueOf(value)
 
 	if val.Kind() == reflect.Map {
 		for _, e := range val.MapKeys() {
 			if e.String() == name {
-				v := val.MapIndex(e)
-
-				return parse(v.Interface(), parts[1])
+				return []interface{}{val.MapIndex(e).Interface()}, nil
 			}
 		}
 
-		return nil, fmt.Errorf("Map element not found: %s", name)
+		return nil, notFoundError{fmt.Sprintf("Map element not found: %s", name)}
 	}
 
-	return nil, fmt.Errorf("Item is not a map, item, or array: %T", item)
+	return nil, fmt.Errorf("Item is not a map, item, or array: %T", value)
 }
 
-func dotQuote(s string) string {
-	return strings.ReplaceAll(s, "\\.", "$$DOT$$")
+// mapKeys returns the keys of a map value in sorted order, so results that depend on the
+// order of the keys are always the same.
+func mapKeys(value interface{}) []string {
+	val := reflect.ValueOf(value)
+	keys := make([]string, 0, val.Len())
+
+	for _, e := range val.MapKeys() {
+		keys = append(keys, e.String())
+	}
+
+	sort.Strings(keys)
+
+	return keys
 }
 
-func dotUnquote(s string, target string) string {
-	return strings.ReplaceAll(s, "$$DOT$$", target)
+// mapValues returns the values of a map value, in the order of the sorted keys.
+func mapValues(value interface{}) []interface{} {
+	val := reflect.ValueOf(value)
+	values := make([]interface{}, 0, val.Len())
+
+	for _, key := range mapKeys(value) {
+		values = append(values, val.MapIndex(reflect.ValueOf(key)).Interface())
+	}
+
+	return values
+}
+
+// descendants returns the values of every key with the given name in the value or anywhere
+// below it, appending them to the result list.
+func descendants(value interface{}, name string, result []interface{}) []interface{} {
+	if isMap(value) {
+		val := reflect.ValueOf(value)
+
+		for _, key := range mapKeys(value) {
+			element := val.MapIndex(reflect.ValueOf(key)).Interface()
+
+			if key == name {
+				result = append(result, element)
+			}
+
+			result = descendants(element, name, result)
+		}
+	} else if list, ok := arrayElements(value); ok {
+		for _, element := range list {
+			result = descendants(element, name, result)
+		}
+	}
+
+	return result
 }
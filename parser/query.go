@@ -0,0 +1,217 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	nameSegment segmentKind = iota
+	indexSegment
+	sliceSegment
+	wildcardSegment
+	filterSegment
+	recursiveSegment
+)
+
+// segment is one step of a query. The query "users[?(@.name=='Alice')].id" has the segments
+// for the name "users", the filter "@.name=='Alice'", and the name "id".
+type segment struct {
+	kind segmentKind
+
+	// The text of the segment. For a name or recursive segment this is the key name, and for
+	// a filter segment this is the filter expression.
+	text string
+
+	// For an index segment this is the index, and for a slice segment these are the start and
+	// end of the slice. A negative value counts from the end of the array.
+	index    int
+	start    int
+	end      int
+	hasStart bool
+	hasEnd   bool
+}
+
+var slicePattern = regexp.MustCompile(`^(-?[0-9]*):(-?[0-9]*)$`)
+
+// multiple returns true if the segment can select more than one item.
+func (s segment) multiple() bool {
+	return s.kind == sliceSegment || s.kind == wildcardSegment || s.kind == filterSegment || s.kind == recursiveSegment
+}
+
+// tokenize converts the text of a query into the list of segments. The query is a series of
+// terms separated by dots, where each term is a key name, an integer index, a slice, or "*".
+// A dot in a key name is escaped as "\.". A term can also be written in brackets, as in
+// "items[0]", "items[-1]", "items[0:3]", "items[*]", "items['a.b']", or "items[?(@.x==1)]".
+// A double dot, as in "..id", finds the key anywhere below the current item.
+func tokenize(item string) ([]segment, error) {
+	var (
+		segments  []segment
+		name      strings.Builder
+		recursive bool
+	)
+
+	// flush adds the name accumulated so far (if any) as a segment.
+	flush := func() {
+		text := name.String()
+		name.Reset()
+
+		if text == "" {
+			return
+		}
+
+		if recursive {
+			segments = append(segments, segment{kind: recursiveSegment, text: text})
+			recursive = false
+
+			return
+		}
+
+		segments = append(segments, termSegment(text))
+	}
+
+	for i := 0; i < len(item); i++ {
+		ch := item[i]
+
+		switch ch {
+		case '\\':
+			if i+1 < len(item) && (item[i+1] == '.' || item[i+1] == '[') {
+				i++
+			}
+
+			name.WriteByte(item[i])
+
+		case '.':
+			flush()
+
+			if i+1 < len(item) && item[i+1] == '.' {
+				recursive = true
+				i++
+			}
+
+		case '[':
+			flush()
+
+			end, err := closingBracket(item, i)
+			if err != nil {
+				return nil, err
+			}
+
+			s, err := bracketSegment(item[i+1 : end])
+			if err != nil {
+				return nil, err
+			}
+
+			if recursive {
+				if s.kind != nameSegment {
+					return nil, fmt.Errorf("Invalid recursive query: %s", item)
+				}
+
+				s.kind = recursiveSegment
+				recursive = false
+			}
+
+			segments = append(segments, s)
+			i = end
+
+		default:
+			name.WriteByte(ch)
+		}
+	}
+
+	flush()
+
+	if recursive {
+		return nil, fmt.Errorf("Missing name after '..' in query: %s", item)
+	}
+
+	return segments, nil
+}
+
+// termSegment classifies a term of a query written in dot-notation.
+func termSegment(text string) segment {
+	if text == "*" {
+		return segment{kind: wildcardSegment, text: text}
+	}
+
+	if index, err := strconv.Atoi(text); err == nil {
+		return segment{kind: indexSegment, text: text, index: index}
+	}
+
+	if parts := slicePattern.FindStringSubmatch(text); parts != nil {
+		s := segment{kind: sliceSegment, text: text}
+
+		if parts[1] != "" {
+			s.start, _ = strconv.Atoi(parts[1])
+			s.hasStart = true
+		}
+
+		if parts[2] != "" {
+			s.end, _ = strconv.Atoi(parts[2])
+			s.hasEnd = true
+		}
+
+		return s
+	}
+
+	return segment{kind: nameSegment, text: text}
+}
+
+// bracketSegment classifies the text found between brackets in a query.
+func bracketSegment(text string) (segment, error) {
+	text = strings.TrimSpace(text)
+
+	if strings.HasPrefix(text, "?") {
+		filter := strings.TrimSpace(text[1:])
+		if !strings.HasPrefix(filter, "(") || !strings.HasSuffix(filter, ")") {
+			return segment{}, fmt.Errorf("Invalid filter expression: %s", text)
+		}
+
+		return segment{kind: filterSegment, text: strings.TrimSpace(filter[1 : len(filter)-1])}, nil
+	}
+
+	if len(text) >= 2 && (text[0] == '\'' || text[0] == '"') && text[len(text)-1] == text[0] {
+		return segment{kind: nameSegment, text: text[1 : len(text)-1]}, nil
+	}
+
+	return termSegment(text), nil
+}
+
+// closingBracket finds the position of the bracket that closes the one at the start
+// position, skipping over any quoted strings and nested brackets.
+func closingBracket(item string, start int) (int, error) {
+	var quote byte
+
+	depth := 0
+
+	for i := start; i < len(item); i++ {
+		ch := item[i]
+
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+
+		case ch == '\'' || ch == '"':
+			quote = ch
+
+		case ch == '[':
+			depth++
+
+		case ch == ']':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("Missing closing bracket in query: %s", item)
+}
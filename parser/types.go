@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// TypeName returns the name of the JSON type of a value returned by GetValues. This is one
+// of "string", "number", "boolean", "null", "array", or "object".
+func TypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+
+	case string:
+		return "string"
+
+	case float64, float32, int, int32, int64:
+		return "number"
+
+	case bool:
+		return "boolean"
+
+	case []interface{}, []string, []float64, []int, []bool:
+		return "array"
+
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return "unknown"
+}
+
+// IsType determines if a value returned by GetValues has the given JSON type name. In addition
+// to the names returned by TypeName, the name "integer" is a number with no fractional part,
+// and "bool" and "map" are accepted as synonyms for "boolean" and "object".
+func IsType(value interface{}, name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	switch name {
+	case "integer", "int":
+		f, ok := value.(float64)
+
+		return ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+
+	case "bool":
+		name = "boolean"
+
+	case "map":
+		name = "object"
+	}
+
+	return TypeName(value) == name
+}
+
+// Equal determines if a value returned by GetValues is equal to the expected value, including
+// its type. The expected value is JSON text, so "5" is the number 5, "\"5\"" is the string "5",
+// and "null" is a null value. If the expected text is not valid JSON, it is treated as a string.
+func Equal(value interface{}, expected string) bool {
+	var expect interface{}
+
+	if err := json.Unmarshal([]byte(expected), &expect); err != nil {
+		expect = expected
+	}
+
+	return reflect.DeepEqual(value, expect)
+}
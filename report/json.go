@@ -0,0 +1,164 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/tucats/apitest/defs"
+)
+
+// jsonReport is the top-level object written to a JSON results report.
+type jsonReport struct {
+	Tests    int        `json:"tests"`
+	Passed   int        `json:"passed"`
+	Failed   int        `json:"failed"`
+	TimedOut int        `json:"timedOut"`
+	Errors   int        `json:"errors"`
+	Skipped  int        `json:"skipped"`
+	Time     time.Time  `json:"time"`
+	Files    []jsonTest `json:"files"`
+}
+
+// jsonTest describes the outcome of a single test file in a JSON results report.
+type jsonTest struct {
+	Path        string           `json:"path"`
+	Suite       string           `json:"suite"`
+	Phase       string           `json:"phase,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Status      string           `json:"status"`
+	Time        *time.Time       `json:"time,omitempty"`
+	Duration    float64          `json:"durationMs"`
+	HTTPStatus  int              `json:"httpStatus,omitempty"`
+	Attempts    int              `json:"attempts,omitempty"`
+	Validations []jsonValidation `json:"validations,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	Reason      string           `json:"reason,omitempty"`
+	Steps       []jsonStep       `json:"steps,omitempty"`
+}
+
+// jsonStep describes the outcome of a single step of a scenario test file. The status
+// is "pass", "fail", or "not run" if an earlier step failed.
+type jsonStep struct {
+	Description string           `json:"description"`
+	Status      string           `json:"status"`
+	Duration    float64          `json:"durationMs"`
+	HTTPStatus  int              `json:"httpStatus,omitempty"`
+	Attempts    int              `json:"attempts,omitempty"`
+	Validations []jsonValidation `json:"validations,omitempty"`
+}
+
+// jsonValidation describes the outcome of an individual validation of a test. The
+// status is "pass", "fail", or "not run" if an earlier failure stopped the test.
+type jsonValidation struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// WriteJSON writes the recorded test results to the given file as a JSON object.
+func WriteJSON(path string) error {
+	report := jsonReport{
+		Time:  time.Now(),
+		Files: []jsonTest{},
+	}
+
+	for _, result := range Results() {
+		item := jsonTest{
+			Path:   result.File,
+			Suite:  result.Suite,
+			Phase:  result.Phase,
+			Status: result.Status(),
+		}
+
+		// Setup and teardown failures are counted as errors, and a skipped setup or teardown
+		// is not counted at all. Tests whose requests timed out are counted separately, not
+		// as test failures.
+		switch {
+		case result.Skip != "" && result.Phase != "":
+			item.Reason = result.Skip
+
+		case result.Skip != "":
+			item.Reason = result.Skip
+			report.Skipped++
+			report.Tests++
+
+		case result.Phase != "":
+			if result.Error != nil {
+				item.Error = result.Error.Error()
+				report.Errors++
+			}
+
+		case result.TimedOut():
+			item.Error = result.Error.Error()
+			report.TimedOut++
+			report.Tests++
+
+		case result.Error != nil:
+			item.Error = result.Error.Error()
+			report.Failed++
+			report.Tests++
+
+		default:
+			report.Passed++
+			report.Tests++
+		}
+
+		if test := result.Test; test != nil {
+			item.Description = test.Description
+			item.Duration = float64(test.Duration) / float64(time.Millisecond)
+			item.HTTPStatus = test.Response.Received
+			item.Attempts = test.Attempts
+
+			if !test.Time.IsZero() {
+				item.Time = &test.Time
+			}
+
+			item.Validations = validations(test)
+
+			for index, status := range result.StepStatus() {
+				step := &test.Steps[index]
+
+				item.Steps = append(item.Steps, jsonStep{
+					Description: step.Description,
+					Status:      status,
+					Duration:    float64(step.Duration) / float64(time.Millisecond),
+					HTTPStatus:  step.Response.Received,
+					Attempts:    step.Attempts,
+					Validations: validations(step),
+				})
+			}
+		}
+
+		report.Files = append(report.Files, item)
+	}
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// validations returns the outcome of each of the validations of a test.
+func validations(test *defs.Test) []jsonValidation {
+	var list []jsonValidation
+
+	for index, validation := range test.Tests {
+		outcome := jsonValidation{Name: validation.Name, Status: "not run"}
+
+		if index < len(test.Results) {
+			outcome.Status = "pass"
+
+			if !test.Results[index].Passed {
+				outcome.Status = "fail"
+				outcome.Error = test.Results[index].Error
+			}
+		}
+
+		list = append(list, outcome)
+	}
+
+	return list
+}
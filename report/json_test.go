@@ -0,0 +1,27 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	recordResults(t)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := WriteJSON(path); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unable to read report, %v", err)
+	}
+
+	// The report is stamped with the time it was written, so replace it before comparing.
+	got = regexp.MustCompile(`(?m)^  "time": ".*",$`).ReplaceAll(got, []byte(`  "time": "REPORT TIME",`))
+
+	compareGolden(t, "report.json", got)
+}
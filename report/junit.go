@@ -0,0 +1,220 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tucats/apitest/defs"
+)
+
+// The following structures define the subset of the JUnit XML report format that is
+// written by apitest. Each directory of tests is a test suite, and each test file is
+// a test case. A failed setup or teardown test, or a test whose request timed out, is
+// reported as an error rather than a failure, and a test that was not run because of
+// an earlier failure is reported as skipped.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	File       string           `xml:"file,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitFailure    `xml:"failure,omitempty"`
+	Error      *junitFailure    `xml:"error,omitempty"`
+	Skipped    *junitSkipped    `xml:"skipped,omitempty"`
+	SystemOut  *junitOutput     `xml:"system-out,omitempty"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the recorded test results to the given file in JUnit XML format. If
+// includeBodies is true, the request and response bodies of each test are included as
+// the system output of the test case.
+func WriteJUnit(path string, includeBodies bool) error {
+	var (
+		report    junitTestSuites
+		total     time.Duration
+		durations []time.Duration
+	)
+
+	for _, result := range Results() {
+		// Results are ordered by suite, so a new suite starts whenever the name changes.
+		if len(report.Suites) == 0 || report.Suites[len(report.Suites)-1].Name != result.Suite {
+			report.Suites = append(report.Suites, junitTestSuite{Name: result.Suite})
+			durations = append(durations, 0)
+		}
+
+		suite := &report.Suites[len(report.Suites)-1]
+		testCase := junitTestCase{
+			Name:      filepath.Base(result.File),
+			ClassName: result.Suite,
+			File:      result.File,
+		}
+
+		var duration time.Duration
+
+		if result.Test != nil {
+			duration = result.Test.Duration
+
+			if result.Test.Description != "" {
+				testCase.Name = result.Test.Description
+			}
+
+			// A test that was retried records the number of attempts made.
+			if result.Test.Attempts > 1 {
+				testCase.Properties = &junitProperties{Properties: []junitProperty{
+					{Name: "attempts", Value: strconv.Itoa(result.Test.Attempts)},
+				}}
+			}
+
+			if text := bodies(result); includeBodies && text != "" {
+				testCase.SystemOut = &junitOutput{Text: text}
+			}
+		}
+
+		testCase.Time = seconds(duration)
+
+		if result.Phase != "" {
+			testCase.Name = result.Phase + ": " + testCase.Name
+		}
+
+		switch {
+		case result.Skip != "":
+			testCase.Skipped = &junitSkipped{Message: result.Skip}
+
+			suite.Skipped++
+			report.Skipped++
+
+		case result.Error != nil && result.Phase != "":
+			testCase.Error = &junitFailure{
+				Message: result.Error.Error(),
+				Text:    result.Error.Error(),
+			}
+
+			suite.Errors++
+			report.Errors++
+
+		case result.TimedOut():
+			testCase.Error = &junitFailure{
+				Message: result.Error.Error(),
+				Type:    "timeout",
+				Text:    result.Error.Error(),
+			}
+
+			suite.Errors++
+			report.Errors++
+
+		case result.Error != nil:
+			testCase.Failure = &junitFailure{
+				Message: result.Error.Error(),
+				Text:    result.Error.Error(),
+			}
+
+			suite.Failures++
+			report.Failures++
+		}
+
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		durations[len(durations)-1] += duration
+		suite.Time = seconds(durations[len(durations)-1])
+
+		report.Tests++
+		total += duration
+	}
+
+	report.Time = seconds(total)
+
+	b, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append([]byte(xml.Header), append(b, '\n')...), 0644)
+}
+
+// bodies formats the request and response bodies of a test for the system output of
+// a test case. For a scenario, this includes the outcome and bodies of each step.
+func bodies(result Result) string {
+	var text strings.Builder
+
+	if len(result.Test.Steps) == 0 {
+		writeBodies(&text, result.Test)
+
+		return text.String()
+	}
+
+	for index, status := range result.StepStatus() {
+		step := &result.Test.Steps[index]
+
+		fmt.Fprintf(&text, "Step %d, %s: %s\n", index+1, step.Description, status)
+		writeBodies(&text, step)
+	}
+
+	return text.String()
+}
+
+// writeBodies writes the request and response bodies of a single test.
+func writeBodies(text *strings.Builder, test *defs.Test) {
+	if body, ok := test.Request.Body.(string); ok && body != "" {
+		text.WriteString("Request body:\n")
+		text.WriteString(body)
+		text.WriteString("\n")
+	}
+
+	if test.Response.Body != "" {
+		text.WriteString("Response body:\n")
+		text.WriteString(test.Response.Body)
+		text.WriteString("\n")
+	}
+}
+
+// seconds formats a duration as the number of seconds, which is how JUnit expresses time.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,140 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/errors"
+)
+
+// recordResults replaces the recorded results with a passed test, a failed test whose error
+// chains two failures, a test that timed out, and a skipped test.
+func recordResults(t *testing.T) {
+	t.Helper()
+
+	lock.Lock()
+	results = nil
+	lock.Unlock()
+
+	t.Cleanup(func() {
+		lock.Lock()
+		results = nil
+		lock.Unlock()
+	})
+
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	failure := errors.New("create user")
+	failure = errors.New("expected status 201, got 400").Chain(failure)
+	failure = errors.New("name: expected 'Bob', got ''").Chain(failure)
+
+	// Add the results out of order, since tests running in parallel can finish in any order.
+	Add(Result{
+		Suite: "api/orders",
+		File:  "api/orders/list.json",
+		Skip:  "login.json failed",
+	})
+
+	Add(Result{
+		Suite: "api/users",
+		File:  "api/users/post.json",
+		Test: &defs.Test{
+			Description: "create user",
+			Request:     defs.RequestObject{Body: `{"name": "Bob"}`},
+			Response:    defs.ResponseObject{Body: `{"error": "invalid name"}`, Received: 400},
+			Tests: []defs.Validation{
+				{Name: "id"},
+				{Name: "name"},
+				{Name: "role"},
+			},
+			Results: []defs.ValidationResult{
+				{Name: "id", Passed: true},
+				{Name: "name", Error: "expected 'Bob', got ''"},
+			},
+			Time:     start.Add(time.Second),
+			Duration: 30 * time.Millisecond,
+			Attempts: 2,
+		},
+		Error: failure,
+	})
+
+	Add(Result{
+		Suite: "api/users",
+		File:  "api/users/get.json",
+		Test: &defs.Test{
+			Description: "get user",
+			Response:    defs.ResponseObject{Body: `{"name": "Alice"}`, Received: 200},
+			Time:        start,
+			Duration:    12 * time.Millisecond,
+			Attempts:    1,
+			Succeeded:   true,
+		},
+	})
+
+	Add(Result{
+		Suite: "api/orders",
+		File:  "api/orders/slow.json",
+		Test: &defs.Test{
+			Description: "slow report",
+			Time:        start.Add(2 * time.Second),
+			Duration:    time.Second,
+			Attempts:    1,
+			TimedOut:    true,
+		},
+		Error: errors.New("request timed out after 1s"),
+	})
+
+	Add(Result{
+		Suite: "api/orders",
+		File:  "api/orders/teardown.json",
+		Phase: Teardown,
+		Skip:  "deadline of the test run passed",
+	})
+}
+
+// compareGolden compares the text of a report with the golden file of the same name in the
+// testdata directory.
+func compareGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	want, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("unable to read golden file, %v", err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("report does not match %s, got:\n%s", name, got)
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	tests := []struct {
+		name   string
+		bodies bool
+		golden string
+	}{
+		{name: "without bodies", golden: "junit.xml"},
+		{name: "with bodies", bodies: true, golden: "junit_bodies.xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recordResults(t)
+
+			path := filepath.Join(t.TempDir(), "report.xml")
+			if err := WriteJUnit(path, tt.bodies); err != nil {
+				t.Fatalf("WriteJUnit() error = %v", err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unable to read report, %v", err)
+			}
+
+			compareGolden(t, tt.golden, got)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package report
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/tucats/apitest/defs"
+)
+
+// Result is the outcome of running a single test file, recorded so it can be written
+// to a report file when all tests have completed.
+type Result struct {
+	// The directory containing the test file. Tests in the same directory are reported
+	// together as a suite.
+	Suite string
+
+	// The path of the test file.
+	File string
+
+	// The phase of the test run for a setup or teardown test file of a directory. This is
+	// empty for an ordinary test.
+	Phase string
+
+	// The test definition, including the request and response bodies. This is nil if the
+	// test file could not be read.
+	Test *defs.Test
+
+	// The error that caused the test to fail, or nil if it passed.
+	Error error
+
+	// If the test was not run because of an earlier failure, this is the reason it was
+	// skipped.
+	Skip string
+}
+
+// These are the phases of the test run for the setup and teardown test files of a directory.
+const (
+	Setup    = "setup"
+	Teardown = "teardown"
+)
+
+var (
+	results []Result
+	lock    sync.Mutex
+)
+
+// Add records the result of a test. This is safe to call from tests running in parallel.
+func Add(result Result) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	results = append(results, result)
+}
+
+// Results returns the recorded test results, ordered by suite and then by file name so
+// the report is the same regardless of the order in which parallel tests completed. The
+// setup test of a suite is first, and the teardown test is last.
+func Results() []Result {
+	lock.Lock()
+	defer lock.Unlock()
+
+	list := make([]Result, len(results))
+	copy(list, results)
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Suite != list[j].Suite {
+			return list[i].Suite < list[j].Suite
+		}
+
+		if list[i].Phase != list[j].Phase {
+			return phaseOrder(list[i].Phase) < phaseOrder(list[j].Phase)
+		}
+
+		return filepath.Base(list[i].File) < filepath.Base(list[j].File)
+	})
+
+	return list
+}
+
+// phaseOrder returns the position of the phase in the results of a suite.
+func phaseOrder(phase string) int {
+	switch phase {
+	case Setup:
+		return 0
+
+	case Teardown:
+		return 2
+
+	default:
+		return 1
+	}
+}
+
+// Status returns a short word describing the outcome of the test.
+func (r Result) Status() string {
+	if r.Skip != "" {
+		return "skip"
+	}
+
+	if r.TimedOut() {
+		return "timeout"
+	}
+
+	if r.Error != nil {
+		return "fail"
+	}
+
+	return "pass"
+}
+
+// TimedOut determines if the test failed because a request did not complete before its
+// timeout or the deadline of the test run.
+func (r Result) TimedOut() bool {
+	return r.Error != nil && r.Test != nil && r.Test.TimedOut
+}
+
+// StepStatus returns a short word describing the outcome of each step of a scenario. A
+// scenario stops at the first step that fails, so the steps after it are "not run".
+func (r Result) StepStatus() []string {
+	if r.Test == nil {
+		return nil
+	}
+
+	status := make([]string, len(r.Test.Steps))
+	failed := false
+
+	for index, step := range r.Test.Steps {
+		switch {
+		case failed:
+			status[index] = "not run"
+
+		case step.Succeeded:
+			status[index] = "pass"
+
+		default:
+			status[index] = "fail"
+			failed = true
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,629 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Maximum depth of nested schema references, to catch a reference that refers to itself
+// without consuming any of the value being validated.
+const maxDepth = 100
+
+// Reader reads the text of a schema file. This lets the caller apply dictionary substitutions
+// or remove comments from the file before it is parsed.
+type Reader func(path string) ([]byte, error)
+
+// Violation describes a single way in which a JSON value does not conform to a schema.
+type Violation struct {
+	// The JSON pointer to the location in the value, such as "/items/0/id". This is an empty
+	// string for the value as a whole.
+	Pointer string
+
+	// A description of the problem.
+	Message string
+}
+
+func (v Violation) Error() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+
+	return pointer + ": " + v.Message
+}
+
+// Validator validates JSON values against a JSON Schema. The supported keywords are type,
+// enum, const, properties, required, additionalProperties, patternProperties, minProperties,
+// maxProperties, items, prefixItems, additionalItems, minItems, maxItems, uniqueItems,
+// contains, minLength, maxLength, pattern, format, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, allOf, anyOf, oneOf, not, if, then, else, and $ref.
+type Validator struct {
+	// The root schema document, and the directory used to locate any files it references.
+	root document
+
+	// The directory that all referenced schema files must be within. If empty, there is
+	// no limit.
+	limit string
+
+	// The function used to read referenced schema files.
+	reader Reader
+
+	// Schema files already read, by absolute path.
+	files map[string]interface{}
+}
+
+// document is a schema document, along with the directory used to resolve references to
+// other schema files found in the document.
+type document struct {
+	schema interface{}
+	dir    string
+}
+
+// New creates a validator for the given schema, which is the result of unmarshalling the
+// JSON text of the schema. References to other schema files are relative to the dir path,
+// and must be within the limit directory. If reader is nil, os.ReadFile is used.
+func New(schema interface{}, dir, limit string, reader Reader) *Validator {
+	if reader == nil {
+		reader = os.ReadFile
+	}
+
+	if limit != "" {
+		limit, _ = filepath.Abs(limit)
+	}
+
+	return &Validator{
+		root:   document{schema: schema, dir: dir},
+		limit:  limit,
+		reader: reader,
+		files:  map[string]interface{}{},
+	}
+}
+
+// Load creates a validator for the schema in the given file. The file, and any schema files
+// it references, must be within the limit directory, if one is given.
+func Load(path, limit string, reader Reader) (*Validator, error) {
+	v := New(nil, "", limit, reader)
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := v.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v.root = document{schema: schema, dir: filepath.Dir(path)}
+
+	return v, nil
+}
+
+// Validate checks the JSON value (the result of unmarshalling JSON text) against the schema,
+// and returns the list of violations found. The list is empty if the value conforms.
+func (v *Validator) Validate(value interface{}) []Violation {
+	var violations []Violation
+
+	v.validate(v.root, v.root.schema, value, "", 0, &violations)
+
+	return violations
+}
+
+// valid returns true if the value conforms to the schema.
+func (v *Validator) valid(doc document, schema interface{}, value interface{}, depth int) bool {
+	var violations []Violation
+
+	v.validate(doc, schema, value, "", depth, &violations)
+
+	return len(violations) == 0
+}
+
+func (v *Validator) validate(doc document, schema interface{}, value interface{}, pointer string, depth int, violations *[]Violation) {
+	report := func(format string, args ...interface{}) {
+		*violations = append(*violations, Violation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if depth > maxDepth {
+		report("schema references are nested too deeply")
+
+		return
+	}
+
+	switch s := schema.(type) {
+	case nil:
+		return
+
+	case bool:
+		if !s {
+			report("no value is allowed here")
+		}
+
+		return
+
+	case map[string]interface{}:
+		// A reference replaces the schema with the one it refers to. Any other keywords in
+		// the schema are also applied.
+		if ref, ok := s["$ref"].(string); ok {
+			refDoc, refSchema, err := v.resolve(doc, ref)
+			if err != nil {
+				report("%v", err)
+			} else {
+				v.validate(refDoc, refSchema, value, pointer, depth+1, violations)
+			}
+		}
+
+		v.validateType(s, value, report)
+		v.validateValue(s, value, report)
+		v.validateString(s, value, report)
+		v.validateNumber(s, value, report)
+		v.validateObject(doc, s, value, pointer, depth, violations, report)
+		v.validateArray(doc, s, value, pointer, depth, violations, report)
+		v.validateCombinations(doc, s, value, pointer, depth, violations, report)
+
+	default:
+		report("invalid schema: %T", schema)
+	}
+}
+
+func (v *Validator) validateType(s map[string]interface{}, value interface{}, report func(string, ...interface{})) {
+	var names []string
+
+	switch t := s["type"].(type) {
+	case nil:
+		return
+
+	case string:
+		names = []string{t}
+
+	case []interface{}:
+		for _, name := range t {
+			names = append(names, fmt.Sprintf("%v", name))
+		}
+	}
+
+	for _, name := range names {
+		if isType(value, name) {
+			return
+		}
+	}
+
+	report("expected type %s, got %s", strings.Join(names, " or "), typeName(value))
+}
+
+func (v *Validator) validateValue(s map[string]interface{}, value interface{}, report func(string, ...interface{})) {
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+
+		for _, item := range enum {
+			if equal(item, value) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			report("value %s is not one of %s", text(value), text(enum))
+		}
+	}
+
+	if constant, ok := s["const"]; ok && !equal(constant, value) {
+		report("value %s is not %s", text(value), text(constant))
+	}
+}
+
+func (v *Validator) validateString(s map[string]interface{}, value interface{}, report func(string, ...interface{})) {
+	str, ok := value.(string)
+	if !ok {
+		return
+	}
+
+	length := len([]rune(str))
+
+	if n, ok := number(s["minLength"]); ok && float64(length) < n {
+		report("length %d is less than minLength %v", length, n)
+	}
+
+	if n, ok := number(s["maxLength"]); ok && float64(length) > n {
+		report("length %d is greater than maxLength %v", length, n)
+	}
+
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := compile(pattern)
+		if err != nil {
+			report("invalid pattern %q: %v", pattern, err)
+		} else if !re.MatchString(str) {
+			report("value %s does not match pattern %q", text(str), pattern)
+		}
+	}
+
+	if format, ok := s["format"].(string); ok && !checkFormat(format, str) {
+		report("value %s is not a valid %s", text(str), format)
+	}
+}
+
+func (v *Validator) validateNumber(s map[string]interface{}, value interface{}, report func(string, ...interface{})) {
+	n, ok := value.(float64)
+	if !ok {
+		return
+	}
+
+	// Older schemas express an exclusive minimum or maximum as a boolean flag that modifies
+	// the minimum or maximum keyword.
+	exclusiveMin, _ := s["exclusiveMinimum"].(bool)
+	exclusiveMax, _ := s["exclusiveMaximum"].(bool)
+
+	if limit, ok := number(s["minimum"]); ok {
+		if exclusiveMin && n <= limit {
+			report("value %v must be greater than %v", n, limit)
+		} else if n < limit {
+			report("value %v is less than minimum %v", n, limit)
+		}
+	}
+
+	if limit, ok := number(s["maximum"]); ok {
+		if exclusiveMax && n >= limit {
+			report("value %v must be less than %v", n, limit)
+		} else if n > limit {
+			report("value %v is greater than maximum %v", n, limit)
+		}
+	}
+
+	if limit, ok := number(s["exclusiveMinimum"]); ok && n <= limit {
+		report("value %v must be greater than %v", n, limit)
+	}
+
+	if limit, ok := number(s["exclusiveMaximum"]); ok && n >= limit {
+		report("value %v must be less than %v", n, limit)
+	}
+
+	if divisor, ok := number(s["multipleOf"]); ok && divisor > 0 {
+		quotient := n / divisor
+		if quotient != float64(int64(quotient)) {
+			report("value %v is not a multiple of %v", n, divisor)
+		}
+	}
+}
+
+func (v *Validator) validateObject(doc document, s map[string]interface{}, value interface{}, pointer string, depth int, violations *[]Violation, report func(string, ...interface{})) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	if n, ok := number(s["minProperties"]); ok && float64(len(object)) < n {
+		report("object has %d properties, fewer than minProperties %v", len(object), n)
+	}
+
+	if n, ok := number(s["maxProperties"]); ok && float64(len(object)) > n {
+		report("object has %d properties, more than maxProperties %v", len(object), n)
+	}
+
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, name := range required {
+			if key, ok := name.(string); ok {
+				if _, found := object[key]; !found {
+					report("missing required property %q", key)
+				}
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	patterns, _ := s["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := s["additionalProperties"]
+
+	for _, key := range sortedKeys(object) {
+		item := object[key]
+		itemPointer := pointer + "/" + escape(key)
+		matched := false
+
+		if property, ok := properties[key]; ok {
+			matched = true
+
+			v.validate(doc, property, item, itemPointer, depth+1, violations)
+		}
+
+		for _, pattern := range sortedKeys(patterns) {
+			re, err := compile(pattern)
+			if err != nil {
+				report("invalid pattern %q: %v", pattern, err)
+
+				continue
+			}
+
+			if re.MatchString(key) {
+				matched = true
+
+				v.validate(doc, patterns[pattern], item, itemPointer, depth+1, violations)
+			}
+		}
+
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok {
+				if !allowed {
+					report("property %q is not allowed", key)
+				}
+			} else {
+				v.validate(doc, additional, item, itemPointer, depth+1, violations)
+			}
+		}
+	}
+}
+
+func (v *Validator) validateArray(doc document, s map[string]interface{}, value interface{}, pointer string, depth int, violations *[]Violation, report func(string, ...interface{})) {
+	array, ok := value.([]interface{})
+	if !ok {
+		return
+	}
+
+	if n, ok := number(s["minItems"]); ok && float64(len(array)) < n {
+		report("array has %d items, fewer than minItems %v", len(array), n)
+	}
+
+	if n, ok := number(s["maxItems"]); ok && float64(len(array)) > n {
+		report("array has %d items, more than maxItems %v", len(array), n)
+	}
+
+	if unique, ok := s["uniqueItems"].(bool); ok && unique {
+		for i := 0; i < len(array); i++ {
+			for j := i + 1; j < len(array); j++ {
+				if equal(array[i], array[j]) {
+					report("items %d and %d are not unique", i, j)
+				}
+			}
+		}
+	}
+
+	// The leading items of the array can each have their own schema, given by "prefixItems",
+	// or by "items" when it is an array in older schemas. The schema for the rest of the
+	// items is then "items" or "additionalItems".
+	var (
+		prefix []interface{}
+		rest   interface{}
+	)
+
+	if list, ok := s["prefixItems"].([]interface{}); ok {
+		prefix = list
+		rest = s["items"]
+	} else if list, ok := s["items"].([]interface{}); ok {
+		prefix = list
+		rest = s["additionalItems"]
+	} else {
+		rest = s["items"]
+	}
+
+	for index, item := range array {
+		itemPointer := pointer + "/" + strconv.Itoa(index)
+
+		if index < len(prefix) {
+			v.validate(doc, prefix[index], item, itemPointer, depth+1, violations)
+		} else if allowed, ok := rest.(bool); ok && !allowed {
+			report("item %d is not allowed", index)
+		} else if rest != nil {
+			v.validate(doc, rest, item, itemPointer, depth+1, violations)
+		}
+	}
+
+	if contains, ok := s["contains"]; ok {
+		found := false
+
+		for _, item := range array {
+			if v.valid(doc, contains, item, depth+1) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			report("array does not contain an item matching the contains schema")
+		}
+	}
+}
+
+func (v *Validator) validateCombinations(doc document, s map[string]interface{}, value interface{}, pointer string, depth int, violations *[]Violation, report func(string, ...interface{})) {
+	if list, ok := s["allOf"].([]interface{}); ok {
+		for _, item := range list {
+			v.validate(doc, item, value, pointer, depth+1, violations)
+		}
+	}
+
+	if list, ok := s["anyOf"].([]interface{}); ok {
+		found := false
+
+		for _, item := range list {
+			if v.valid(doc, item, value, depth+1) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			report("value does not match any of the anyOf schemas")
+		}
+	}
+
+	if list, ok := s["oneOf"].([]interface{}); ok {
+		count := 0
+
+		for _, item := range list {
+			if v.valid(doc, item, value, depth+1) {
+				count++
+			}
+		}
+
+		if count != 1 {
+			report("value matches %d of the oneOf schemas, expected exactly one", count)
+		}
+	}
+
+	if not, ok := s["not"]; ok && v.valid(doc, not, value, depth+1) {
+		report("value must not match the not schema")
+	}
+
+	if condition, ok := s["if"]; ok {
+		if v.valid(doc, condition, value, depth+1) {
+			if then, ok := s["then"]; ok {
+				v.validate(doc, then, value, pointer, depth+1, violations)
+			}
+		} else if otherwise, ok := s["else"]; ok {
+			v.validate(doc, otherwise, value, pointer, depth+1, violations)
+		}
+	}
+}
+
+// resolve finds the schema that a $ref value refers to. The reference is a JSON pointer
+// within the current document such as "#/$defs/user", or the path of another schema file
+// relative to the current document, optionally followed by a JSON pointer. References to
+// network locations are not supported.
+func (v *Validator) resolve(doc document, ref string) (document, interface{}, error) {
+	path, fragment, _ := strings.Cut(ref, "#")
+
+	if path != "" {
+		if u, err := url.Parse(path); err == nil && u.Scheme != "" && len(u.Scheme) > 1 {
+			return doc, nil, fmt.Errorf("reference %q is not a local file", ref)
+		}
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(doc.dir, path)
+		}
+
+		schema, err := v.readFile(path)
+		if err != nil {
+			return doc, nil, fmt.Errorf("reference %q: %v", ref, err)
+		}
+
+		doc = document{schema: schema, dir: filepath.Dir(path)}
+	}
+
+	schema, err := pointerValue(doc.schema, fragment)
+	if err != nil {
+		return doc, nil, fmt.Errorf("reference %q: %v", ref, err)
+	}
+
+	return doc, schema, nil
+}
+
+// readFile reads and parses a schema file, which must be within the limit directory.
+func (v *Validator) readFile(path string) (interface{}, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.limit != "" {
+		relative, err := filepath.Rel(v.limit, path)
+		if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("schema file %s is outside of %s", path, v.limit)
+		}
+	}
+
+	return v.parseFile(path)
+}
+
+// parseFile reads and parses the schema file with the given absolute path.
+func (v *Validator) parseFile(path string) (interface{}, error) {
+	var schema interface{}
+
+	if schema, found := v.files[path]; found {
+		return schema, nil
+	}
+
+	b, err := v.reader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema file %s: %v", path, err)
+	}
+
+	v.files[path] = schema
+
+	return schema, nil
+}
+
+// pointerValue finds the part of a document referred to by a JSON pointer.
+func pointerValue(document interface{}, pointer string) (interface{}, error) {
+	if pointer == "" || pointer == "/" {
+		return document, nil
+	}
+
+	if unescaped, err := url.PathUnescape(pointer); err == nil {
+		pointer = unescaped
+	}
+
+	current := document
+
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+
+		switch actual := current.(type) {
+		case map[string]interface{}:
+			next, ok := actual[token]
+			if !ok {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+
+			current = next
+
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(actual) {
+				return nil, fmt.Errorf("%s not found", pointer)
+			}
+
+			current = actual[index]
+
+		default:
+			return nil, fmt.Errorf("%s not found", pointer)
+		}
+	}
+
+	return current, nil
+}
+
+// escape converts a key name to a JSON pointer token.
+func escape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func number(value interface{}) (float64, bool) {
+	n, ok := value.(float64)
+
+	return n, ok
+}
+
+func equal(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// text formats a value as JSON for use in a message.
+func text(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(b)
+}
@@ -0,0 +1,170 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	user := `{
+		"type": "object",
+		"required": ["id", "name"],
+		"additionalProperties": false,
+		"properties": {
+			"id": { "type": "string", "format": "uuid" },
+			"name": { "type": "string", "minLength": 1 },
+			"age": { "type": "integer", "minimum": 0, "exclusiveMaximum": 150 },
+			"role": { "enum": ["admin", "user"] },
+			"tags": { "type": "array", "items": { "type": "string" }, "uniqueItems": true },
+			"manager": { "$ref": "#" }
+		}
+	}`
+
+	tests := []struct {
+		name   string
+		schema string
+		value  string
+		want   []string
+	}{
+		{
+			name:   "valid object",
+			schema: user,
+			value:  `{ "id": "3f2b1c9e-8a4d-4e2b-9c1a-1234567890ab", "name": "Alice", "age": 30, "tags": ["a", "b"] }`,
+		},
+		{
+			name:   "every violation is reported",
+			schema: user,
+			value:  `{ "id": "not-a-uuid", "age": 30.5, "role": "guest", "extra": 1 }`,
+			want: []string{
+				`/: missing required property "name"`,
+				`/age: expected type integer, got number`,
+				`/: property "extra" is not allowed`,
+				`/id: value "not-a-uuid" is not a valid uuid`,
+				`/role: value "guest" is not one of ["admin","user"]`,
+			},
+		},
+		{
+			name:   "nested array items",
+			schema: user,
+			value:  `{ "id": "3f2b1c9e-8a4d-4e2b-9c1a-1234567890ab", "name": "Alice", "tags": ["a", 2, "a"] }`,
+			want: []string{
+				`/tags: items 0 and 2 are not unique`,
+				`/tags/1: expected type string, got number`,
+			},
+		},
+		{
+			name:   "recursive reference",
+			schema: user,
+			value:  `{ "id": "3f2b1c9e-8a4d-4e2b-9c1a-1234567890ab", "name": "Alice", "manager": { "id": "3f2b1c9e-8a4d-4e2b-9c1a-1234567890ab", "name": "" } }`,
+			want: []string{
+				`/manager/name: length 0 is less than minLength 1`,
+			},
+		},
+		{
+			name:   "escaped pointer",
+			schema: `{ "additionalProperties": { "type": "number" } }`,
+			value:  `{ "a/b": "x" }`,
+			want: []string{
+				`/a~1b: expected type number, got string`,
+			},
+		},
+		{
+			name:   "definitions and combinations",
+			schema: `{ "$defs": { "positive": { "type": "number", "minimum": 1 } }, "oneOf": [ { "$ref": "#/$defs/positive" }, { "type": "string" } ] }`,
+			value:  `0`,
+			want: []string{
+				`/: value matches 0 of the oneOf schemas, expected exactly one`,
+			},
+		},
+		{
+			name:   "tuple items",
+			schema: `{ "type": "array", "prefixItems": [ { "type": "string" }, { "type": "number" } ], "items": false }`,
+			value:  `["a", 1, true]`,
+			want: []string{
+				`/: item 2 is not allowed`,
+			},
+		},
+		{
+			name:   "remote reference",
+			schema: `{ "$ref": "https://example.com/schema.json" }`,
+			value:  `{}`,
+			want: []string{
+				`/: reference "https://example.com/schema.json" is not a local file`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema, value interface{}
+
+			if err := json.Unmarshal([]byte(tt.schema), &schema); err != nil {
+				t.Fatalf("invalid schema: %v", err)
+			}
+
+			if err := json.Unmarshal([]byte(tt.value), &value); err != nil {
+				t.Fatalf("invalid value: %v", err)
+			}
+
+			violations := New(schema, "", "", nil).Validate(value)
+
+			got := make([]string, len(violations))
+			for i, violation := range violations {
+				got[i] = violation.Error()
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileReferences(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	files := map[string]string{
+		filepath.Join(root, "user.json"):          `{ "type": "object", "properties": { "address": { "$ref": "types/address.json#/$defs/address" } } }`,
+		filepath.Join(root, "types/address.json"): `{ "$defs": { "address": { "type": "object", "required": ["city"] } } }`,
+		filepath.Join(root, "escape.json"):        `{ "$ref": "` + filepath.Join(outside, "other.json") + `" }`,
+		filepath.Join(outside, "other.json"):      `{ "type": "string" }`,
+	}
+
+	for path, text := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v, err := Load(filepath.Join(root, "user.json"), root, nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	violations := v.Validate(map[string]interface{}{"address": map[string]interface{}{}})
+	if len(violations) != 1 || violations[0].Error() != `/address: missing required property "city"` {
+		t.Errorf("Validate() = %v", violations)
+	}
+
+	v, err = Load(filepath.Join(root, "escape.json"), root, nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	violations = v.Validate("text")
+	if len(violations) != 1 || !strings.Contains(violations[0].Message, "is outside of") {
+		t.Errorf("Validate() = %v, want a reference outside the root to fail", violations)
+	}
+
+	_, err = Load(filepath.Join(outside, "other.json"), root, nil)
+	if err == nil || !strings.Contains(err.Error(), "is outside of") {
+		t.Errorf("Load() error = %v, want a file outside the root to fail", err)
+	}
+}
@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// isType determines if a value has the named JSON Schema type.
+func isType(value interface{}, name string) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(float64)
+
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+
+	case "number":
+		_, ok := value.(float64)
+
+		return ok
+	}
+
+	return typeName(value) == name
+}
+
+// typeName returns the JSON Schema type name of a value.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return "unknown"
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// checkFormat determines if the string is valid for the named format. Formats that are not
+// recognized are not checked.
+func checkFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+
+		return err == nil
+
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+
+		return err == nil
+
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", value)
+		if err != nil {
+			_, err = time.Parse("15:04:05.999999999Z07:00", value)
+		}
+
+		return err == nil
+
+	case "email":
+		at := strings.LastIndex(value, "@")
+
+		return at > 0 && at < len(value)-1 && !strings.ContainsAny(value, " \t\n")
+
+	case "uuid":
+		return uuidPattern.MatchString(value)
+
+	case "uri":
+		u, err := url.Parse(value)
+
+		return err == nil && u.Scheme != ""
+
+	case "ipv4":
+		ip := net.ParseIP(value)
+
+		return ip != nil && ip.To4() != nil && strings.Contains(value, ".")
+
+	case "ipv6":
+		ip := net.ParseIP(value)
+
+		return ip != nil && strings.Contains(value, ":")
+	}
+
+	return true
+}
+
+var (
+	patterns    = map[string]*regexp.Regexp{}
+	patternLock sync.Mutex
+)
+
+// compile compiles a regular expression pattern, keeping the result so each pattern is only
+// compiled once.
+func compile(pattern string) (*regexp.Regexp, error) {
+	patternLock.Lock()
+	defer patternLock.Unlock()
+
+	if re, found := patterns[pattern]; found {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns[pattern] = re
+
+	return re, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tucats/apitest/parser"
+	"github.com/tucats/apitest/tags"
+)
+
+// tagExpression is the expression given by the --tags option, which selects the tests to
+// run by their tags. If nil, all tests are run.
+var tagExpression *tags.Expression
+
+// testHeader is the part of a test file that is read before the test is run, to decide if
+// it is to be run and in what order.
+type testHeader struct {
+	ID        string   `json:"id"`
+	DependsOn []string `json:"dependsOn"`
+	Tags      []string `json:"tags"`
+}
+
+// readHeader reads the header of a test file. A file that cannot be parsed has an empty
+// header; the error is reported when the test is run.
+func readHeader(path, name string) (testHeader, error) {
+	var header testHeader
+
+	b, err := os.ReadFile(filepath.Join(path, name))
+	if err != nil {
+		return header, err
+	}
+
+	_ = json.Unmarshal(parser.RemoveComments(b), &header)
+
+	return header, nil
+}
+
+// isTestFile determines if a file in a test suite directory is a test. This is any JSON
+// file other than the reserved files.
+func isTestFile(name string) bool {
+	if name == "dictionary.json" || name == setupFile || name == teardownFile {
+		return false
+	}
+
+	return filepath.Ext(name) == ".json"
+}
+
+// matchesFilter determines if a test file is selected by the --filter option, if given.
+func matchesFilter(name string) bool {
+	return filter == "" || strings.Contains(name, filter)
+}
+
+// isSelected determines if a test file is selected by the --filter and --tags options. The
+// tags of the test are its own tags along with the default tags of its directory.
+func isSelected(path, name string, directory []string) bool {
+	if !matchesFilter(name) {
+		return false
+	}
+
+	if tagExpression == nil {
+		return true
+	}
+
+	header, err := readHeader(path, name)
+	if err != nil {
+		return false
+	}
+
+	return tagExpression.Match(append(append([]string{}, directory...), header.Tags...))
+}
+
+// directoryTags returns the default tags of the tests in a directory. These are the tags of
+// the parent directory, along with any listed in the "TAGS" value of the dictionary.json file
+// of the directory, separated by commas.
+func directoryTags(path string, parent []string) []string {
+	var definitions map[string]string
+
+	result := append([]string{}, parent...)
+
+	b, err := os.ReadFile(filepath.Join(path, "dictionary.json"))
+	if err != nil {
+		return result
+	}
+
+	if err := json.Unmarshal(parser.RemoveComments(b), &definitions); err != nil {
+		return result
+	}
+
+	for _, tag := range strings.Split(definitions["TAGS"], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
+
+// hasSelectedTests determines if any of the tests in a directory or its subdirectories are
+// selected by the --filter and --tags options, given the default tags of the directory.
+func hasSelectedTests(path string, defaults []string) bool {
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return false
+	}
+
+	for _, file := range files {
+		name := file.Name()
+
+		if file.IsDir() {
+			subdir := filepath.Join(path, name)
+			if hasSelectedTests(subdir, directoryTags(subdir, defaults)) {
+				return true
+			}
+
+			continue
+		}
+
+		if isTestFile(name) && isSelected(path, name, defaults) {
+			return true
+		}
+	}
+
+	return false
+}
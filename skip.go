@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tucats/apitest/tester"
+)
+
+// skipState records the reason that the rest of the tests in a scope are skipped. It is
+// empty until a failure causes the tests to be skipped, and is safe to use from tests
+// running in parallel.
+type skipState struct {
+	lock   sync.Mutex
+	reason string
+
+	// For a directory, this is true if a failure of any test in the directory causes the
+	// rest of the directory to be skipped.
+	failFast bool
+}
+
+// runSkip records the reason that the rest of the test run is skipped, after a test fails
+// that has the abort flag set, any test fails when --fail-fast is given, or the deadline
+// given with --deadline passes.
+var runSkip skipState
+
+// set records the reason tests are skipped. Only the first reason is kept.
+func (s *skipState) set(reason string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.reason == "" {
+		s.reason = reason
+	}
+}
+
+// get returns the reason tests are skipped, or an empty string if they are not.
+func (s *skipState) get() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.reason
+}
+
+// skipReason returns the reason a test in the directory is skipped, either because the rest
+// of the run is skipped or because the rest of the directory is.
+func skipReason(directory *skipState) string {
+	if reason := runSkipReason(); reason != "" {
+		return reason
+	}
+
+	return directory.get()
+}
+
+// runSkipReason returns the reason the rest of the test run is skipped, or an empty string
+// if it is not. Once the deadline of the run has passed, the rest of the run is skipped.
+func runSkipReason() string {
+	if deadlinePassed() {
+		runSkip.set("deadline of the test run passed")
+	}
+
+	return runSkip.get()
+}
+
+// deadlinePassed determines if the deadline of the test run has passed. This is false if the
+// run has no deadline.
+func deadlinePassed() bool {
+	return !tester.Deadline.IsZero() && !time.Now().Before(tester.Deadline)
+}
+
+// skipTests reports each of the selected tests in a directory and its subdirectories as
+// skipped, without running them. The parent list is the default tags of the parent
+// directory.
+func skipTests(path, reason string, parent []string) error {
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	defaults := directoryTags(path, parent)
+	fileNames := make([]string, 0)
+
+	for _, file := range files {
+		name := file.Name()
+
+		if file.IsDir() {
+			if err := skipTests(filepath.Join(path, name), reason, defaults); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if isTestFile(name) && isSelected(path, name, defaults) {
+			fileNames = append(fileNames, name)
+		}
+	}
+
+	sort.Strings(fileNames)
+
+	for _, name := range fileNames {
+		reportSkipped(path, filepath.Join(path, name), reason)
+	}
+
+	return nil
+}
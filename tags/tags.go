@@ -0,0 +1,221 @@
+// Package tags selects tests by the tags they have, using a boolean expression of tag names
+// such as "smoke && !slow".
+package tags
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Expression is a parsed tag expression. A nil expression matches every list of tags.
+type Expression struct {
+	// The operator of this node of the expression, which is one of "tag", "!", "&&", or "||".
+	operator string
+
+	// For a "tag" node, this is the name of the tag.
+	name string
+
+	// The operands of the "!", "&&", and "||" operators.
+	operands []*Expression
+}
+
+// Parse parses a tag expression. An expression is made of tag names, the operators "!"
+// (not), "&&" (and), and "||" (or), and parentheses. "!" has the highest precedence and
+// "||" the lowest. A tag name can contain letters, digits, and the characters "-", "_",
+// ".", and ":". An empty expression results in nil, which matches every list of tags.
+func Parse(text string) (*Expression, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	p := &tagParser{tokens: tokens}
+
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.position < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s' in tag expression", p.tokens[p.position])
+	}
+
+	return e, nil
+}
+
+// Match determines if a list of tags satisfies the expression. Tag names are compared
+// without regard to case.
+func (e *Expression) Match(tags []string) bool {
+	if e == nil {
+		return true
+	}
+
+	switch e.operator {
+	case "!":
+		return !e.operands[0].Match(tags)
+
+	case "&&":
+		for _, operand := range e.operands {
+			if !operand.Match(tags) {
+				return false
+			}
+		}
+
+		return true
+
+	case "||":
+		for _, operand := range e.operands {
+			if operand.Match(tags) {
+				return true
+			}
+		}
+
+		return false
+
+	default:
+		for _, tag := range tags {
+			if strings.EqualFold(strings.TrimSpace(tag), e.name) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// tagParser is a recursive descent parser for the tokens of a tag expression.
+type tagParser struct {
+	tokens   []string
+	position int
+}
+
+// or parses a list of operands separated by "||".
+func (p *tagParser) or() (*Expression, error) {
+	return p.list("||", p.and)
+}
+
+// and parses a list of operands separated by "&&".
+func (p *tagParser) and() (*Expression, error) {
+	return p.list("&&", p.unary)
+}
+
+// list parses a list of operands separated by the operator. If there is only one operand,
+// it is the result.
+func (p *tagParser) list(operator string, operand func() (*Expression, error)) (*Expression, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Expression{operator: operator, operands: []*Expression{first}}
+
+	for p.peek() == operator {
+		p.position++
+
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		result.operands = append(result.operands, next)
+	}
+
+	if len(result.operands) == 1 {
+		return first, nil
+	}
+
+	return result, nil
+}
+
+// unary parses a tag name, a negated operand, or a parenthesized expression.
+func (p *tagParser) unary() (*Expression, error) {
+	token := p.peek()
+	p.position++
+
+	switch token {
+	case "":
+		return nil, fmt.Errorf("unexpected end of tag expression")
+
+	case "!":
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Expression{operator: "!", operands: []*Expression{operand}}, nil
+
+	case "(":
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing ')' in tag expression")
+		}
+
+		p.position++
+
+		return e, nil
+
+	case ")", "&&", "||":
+		return nil, fmt.Errorf("unexpected '%s' in tag expression", token)
+	}
+
+	return &Expression{operator: "tag", name: token}, nil
+}
+
+// peek returns the next token, or an empty string at the end of the expression.
+func (p *tagParser) peek() string {
+	if p.position >= len(p.tokens) {
+		return ""
+	}
+
+	return p.tokens[p.position]
+}
+
+// tokenize breaks a tag expression into tag names, operators, and parentheses.
+func tokenize(text string) ([]string, error) {
+	var tokens []string
+
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+
+		switch {
+		case unicode.IsSpace(ch):
+			continue
+
+		case ch == '!' || ch == '(' || ch == ')':
+			tokens = append(tokens, string(ch))
+
+		case (ch == '&' || ch == '|') && i+1 < len(runes) && runes[i+1] == ch:
+			tokens = append(tokens, string(ch)+string(ch))
+			i++
+
+		case isNameChar(ch):
+			start := i
+			for i+1 < len(runes) && isNameChar(runes[i+1]) {
+				i++
+			}
+
+			tokens = append(tokens, string(runes[start:i+1]))
+
+		default:
+			return nil, fmt.Errorf("invalid character '%c' in tag expression", ch)
+		}
+	}
+
+	return tokens, nil
+}
+
+// isNameChar determines if a character can be part of a tag name.
+func isNameChar(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || strings.ContainsRune("-_.:", ch)
+}
@@ -0,0 +1,58 @@
+package tags
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expression string
+		tags       []string
+		want       bool
+	}{
+		{"", nil, true},
+		{"smoke", []string{"smoke"}, true},
+		{"smoke", []string{"Smoke", "slow"}, true},
+		{"smoke", []string{"slow"}, false},
+		{"smoke", nil, false},
+		{"!slow", nil, true},
+		{"smoke && !slow", []string{"smoke"}, true},
+		{"smoke && !slow", []string{"smoke", "slow"}, false},
+		{"smoke || admin", []string{"admin"}, true},
+		{"smoke || admin && slow", []string{"admin"}, false},
+		{"smoke || admin && slow", []string{"smoke"}, true},
+		{"(smoke || admin) && slow", []string{"smoke"}, false},
+		{"(smoke || admin) && slow", []string{"admin", "slow"}, true},
+		{"!!smoke", []string{"smoke"}, true},
+		{"!(a || b)", []string{"c"}, true},
+		{"team:api && v1.2", []string{"team:api", "v1.2"}, true},
+	}
+
+	for _, tt := range tests {
+		e, err := Parse(tt.expression)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expression, err)
+
+			continue
+		}
+
+		if got := e.Match(tt.tags); got != tt.want {
+			t.Errorf("Parse(%q).Match(%v) = %v, want %v", tt.expression, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expression := range []string{
+		"smoke &&",
+		"&& smoke",
+		"(smoke",
+		"smoke)",
+		"smoke slow",
+		"smoke & slow",
+		"smoke, slow",
+		"!",
+	} {
+		if _, err := Parse(expression); err == nil {
+			t.Errorf("Parse(%q) expected an error", expression)
+		}
+	}
+}
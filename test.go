@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
 
 	"github.com/tucats/apitest/defs"
 	"github.com/tucats/apitest/dictionary"
@@ -14,7 +13,10 @@ import (
 	"github.com/tucats/apitest/tester"
 )
 
-func TestFile(filename string) (time.Duration, error) {
+// TestFile loads the test definition in the given file and runs it. The test object is
+// returned even when the test fails, so the caller can report on it; it is nil only if
+// the file could not be read.
+func TestFile(dict *dictionary.Dictionary, filename string) (*defs.Test, error) {
 	var (
 		err  error
 		test defs.Test
@@ -23,23 +25,32 @@ func TestFile(filename string) (time.Duration, error) {
 	// Load the test definition form the file into a Test object.
 	b, err := os.ReadFile(filename)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	b = []byte(dictionary.Apply(string(parser.RemoveComments(b))))
+	b = parser.RemoveComments(b)
+
+	// If the file is a scenario, run each of its steps as a test.
+	if steps, ok := scenarioSteps(b); ok {
+		return runScenario(dict, filename, b, steps)
+	}
+
+	b = []byte(dictionary.Apply(dict, string(b)))
 
 	// Validate the test definition JSON
 	err = validate.Validate(string(b))
 	if err != nil {
-		return 0, fmt.Errorf("test definition validation error: %v", err)
+		return &test, fmt.Errorf("test definition validation error: %v", err)
 	}
 
 	// Now unmarshal the JSON into the test structure
 	err = json.Unmarshal(b, &test)
 	if err != nil {
-		return 0, err
+		return &test, err
 	}
 
+	test.File = filename
+
 	if logging.Verbose {
 		base := filepath.Base(filename)
 		desc := ""
@@ -51,19 +62,131 @@ func TestFile(filename string) (time.Duration, error) {
 		fmt.Printf("Running %s%s\n", base, desc)
 	}
 
-	return run(&test)
+	err = run(dict, &test)
+	test.Succeeded = err == nil
+
+	return &test, err
+}
+
+// scenarioSteps determines if the text of a test file is a scenario, which has a "steps"
+// array instead of a single test, and returns the text of each step if it is. The steps are
+// not parsed until each is run, so values saved by earlier steps can be substituted into
+// later ones.
+func scenarioSteps(b []byte) ([]json.RawMessage, bool) {
+	var scenario struct {
+		Steps []json.RawMessage `json:"steps"`
+	}
+
+	if err := json.Unmarshal(b, &scenario); err != nil || scenario.Steps == nil {
+		return nil, false
+	}
+
+	return scenario.Steps, true
 }
 
-func run(test *defs.Test) (time.Duration, error) {
+// runScenario runs the steps of a scenario file in order, stopping at the first step that
+// fails. The steps share a copy of the dictionary, so values saved by one step are available
+// to the steps that follow it, but not to other test files. The result is a test that
+// describes the scenario as a whole, with the outcome of each step in its Steps list.
+func runScenario(dict *dictionary.Dictionary, filename string, b []byte, steps []json.RawMessage) (*defs.Test, error) {
 	var err error
 
-	err = tester.ExecuteTest(test)
+	var scenario defs.Test
+
+	if err := json.Unmarshal(b, &scenario); err != nil {
+		return &scenario, err
+	}
+
+	scenario.File = filename
+	scenario.Steps = make([]defs.Test, len(steps))
+
+	if scenario.Description == "" {
+		return &scenario, fmt.Errorf("scenario definition validation error: missing description")
+	}
+
+	if len(steps) == 0 {
+		return &scenario, fmt.Errorf("scenario definition validation error: no steps")
+	}
+
+	if logging.Verbose {
+		fmt.Printf("Running scenario %s, %s\n", filepath.Base(filename), scenario.Description)
+	}
+
+	local := dict.Clone()
+
+	for index, text := range steps {
+		step := &scenario.Steps[index]
+		step.File = filename
+		step.Step = index + 1
+
+		// Once a step fails, the remaining steps are not run, but their descriptions are
+		// kept for reporting.
+		if err != nil {
+			_ = json.Unmarshal(text, step)
+
+			continue
+		}
+
+		err = runStep(local, step, text)
+		if err != nil {
+			err = fmt.Errorf("step %d: %w", index+1, err)
+
+			// A step that fails with the abort flag set aborts the run, the same as a test.
+			scenario.Abort = scenario.Abort || step.Abort
+			scenario.TimedOut = step.TimedOut
+		}
+
+		if scenario.Time.IsZero() {
+			scenario.Time = step.Time
+		}
+
+		scenario.Duration += step.Duration
+	}
+
+	scenario.Succeeded = err == nil
+
+	return &scenario, err
+}
+
+// runStep runs a single step of a scenario, using the dictionary of the scenario.
+func runStep(dict *dictionary.Dictionary, step *defs.Test, text []byte) error {
+	b := dictionary.Apply(dict, string(text))
+
+	err := validate.Validate(b)
+	if err != nil {
+		return fmt.Errorf("test definition validation error: %v", err)
+	}
+
+	err = json.Unmarshal([]byte(b), step)
 	if err != nil {
-		return 0, err
+		return err
+	}
+
+	if logging.Verbose {
+		fmt.Printf("  Step %s\n", step.Description)
+	}
+
+	err = run(dict, step)
+	step.Succeeded = err == nil
+
+	return err
+}
+
+func run(dict *dictionary.Dictionary, test *defs.Test) error {
+	var err error
+
+	err = tester.ExecuteWithRetry(dict, test)
+	if err != nil {
+		return err
 	}
 
 	// Save any results from the test back in the dictionary.
-	err = dictionary.Update(test.Response.Body, test.Response.Save)
+	response := dictionary.Response{
+		Body:    test.Response.Body,
+		Status:  test.Response.Received,
+		Headers: test.Response.ReceivedHeaders,
+		Cookies: test.Response.ReceivedCookies,
+	}
 
-	return test.Duration, err
+	return dictionary.Update(dict, response, test.Response.Save)
 }
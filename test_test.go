@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+	"github.com/tucats/apitest/tester"
+	"github.com/tucats/validator"
+)
+
+func TestScenarioSnapshots(t *testing.T) {
+	var err error
+
+	validate, err = validator.New(&defs.Test{})
+	if err != nil {
+		t.Fatalf("unable to create the test validator, %v", err)
+	}
+
+	// The server responds with the name in the path, so each step has a different body.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"name": "%s"}`, strings.TrimPrefix(r.URL.Path, "/"))
+	}))
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "flow.json")
+	scenario := fmt.Sprintf(`{
+		"description": "two users",
+		"steps": [
+			{ "description": "alice", "request": { "method": "GET", "endpoint": "%[1]s/alice" }, "response": { "status": 200, "snapshot": {} } },
+			{ "description": "bob", "request": { "method": "GET", "endpoint": "%[1]s/bob" }, "response": { "status": 200, "snapshot": {} } }
+		]
+	}`, server.URL)
+
+	if err := os.WriteFile(file, []byte(scenario), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writing the snapshots creates one file for each step.
+	tester.UpdateSnapshots = true
+	t.Cleanup(func() { tester.UpdateSnapshots = false })
+
+	if _, err := TestFile(dictionary.New(), file); err != nil {
+		t.Fatalf("TestFile() with --update-snapshots error = %v", err)
+	}
+
+	for step, name := range []string{"alice", "bob"} {
+		b, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("flow.step%d.snapshot", step+1)))
+		if err != nil {
+			t.Fatalf("unable to read the snapshot of step %d, %v", step+1, err)
+		}
+
+		if !strings.Contains(string(b), name) {
+			t.Errorf("snapshot of step %d = %s, want the body for %s", step+1, b, name)
+		}
+	}
+
+	// Each step is then compared with its own snapshot.
+	tester.UpdateSnapshots = false
+
+	if _, err := TestFile(dictionary.New(), file); err != nil {
+		t.Errorf("TestFile() error = %v", err)
+	}
+}
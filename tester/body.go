@@ -0,0 +1,40 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+	"github.com/tucats/apitest/diff"
+	"github.com/tucats/apitest/logging"
+)
+
+// validateBody compares the response body of the test to the expected body, after applying
+// the dictionary to the expected body. When both are JSON, they are compared structurally
+// and the error lists each difference between them; otherwise they must match exactly.
+func validateBody(dict *dictionary.Dictionary, expected string, test *defs.Test) error {
+	var expectedValue, actualValue interface{}
+
+	if logging.Verbose {
+		fmt.Println("  Validating response body")
+	}
+
+	expected = dictionary.Apply(dict, expected)
+	actual := test.Response.Body
+
+	if json.Unmarshal([]byte(expected), &expectedValue) == nil && json.Unmarshal([]byte(actual), &actualValue) == nil {
+		differences := diff.Compare(expectedValue, actualValue, nil)
+		if len(differences) > 0 {
+			return fmt.Errorf("body: response body does not match expected body\n%s", indent(diff.Format(differences)))
+		}
+
+		return nil
+	}
+
+	if actual != expected {
+		return fmt.Errorf("body: expected '%s', got '%s'", expected, actual)
+	}
+
+	return nil
+}
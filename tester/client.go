@@ -0,0 +1,76 @@
+package tester
+
+import (
+	"crypto/tls"
+	"net/http"
+	"sync"
+	"time"
+
+	"gopkg.in/resty.v1"
+)
+
+// NoReuse is set by the --no-reuse command line option, so each request is made with a new
+// client and a new connection to the server, rather than reusing the connections of earlier
+// requests.
+var NoReuse bool
+
+// These control the pool of connections kept open by each shared client for later requests.
+const (
+	maxIdleConnections = 100
+	idleTimeout        = 90 * time.Second
+)
+
+var (
+	clients    = map[tlsSettings]*resty.Client{}
+	clientLock sync.Mutex
+)
+
+// httpClient returns the client used to make a request with the given TLS settings. All the
+// requests of a test run with the same TLS settings share one client, so connections to the
+// server are kept open and TLS sessions are resumed between tests. If NoReuse is set, a new
+// client is returned for each request instead.
+func httpClient(settings tlsSettings) (*resty.Client, error) {
+	if NoReuse {
+		return newClient(settings)
+	}
+
+	clientLock.Lock()
+	defer clientLock.Unlock()
+
+	if client, ok := clients[settings]; ok {
+		return client, nil
+	}
+
+	client, err := newClient(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	clients[settings] = client
+
+	return client, nil
+}
+
+// newClient creates a client for making requests with the given TLS settings. The client does
+// not keep cookies, so the cookies set by the response to one request are not sent with the
+// next.
+func newClient(settings tlsSettings) (*resty.Client, error) {
+	tlsConfiguration, err := settings.config()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig:     tlsConfiguration,
+		MaxIdleConns:        maxIdleConnections,
+		MaxIdleConnsPerHost: maxIdleConnections,
+		IdleConnTimeout:     idleTimeout,
+		DisableKeepAlives:   NoReuse,
+	}
+
+	if !NoReuse {
+		tlsConfiguration.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+
+	return resty.NewWithClient(&http.Client{Transport: transport}), nil
+}
@@ -0,0 +1,111 @@
+package tester
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+	"gopkg.in/resty.v1"
+)
+
+// resetClients starts a test with no shared clients, and the given NoReuse setting.
+func resetClients(t *testing.T, noReuse bool) {
+	t.Helper()
+
+	clientLock.Lock()
+	clients = map[tlsSettings]*resty.Client{}
+	clientLock.Unlock()
+
+	NoReuse = noReuse
+
+	t.Cleanup(func() { NoReuse = false })
+}
+
+func TestHTTPClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		noReuse  bool
+		first    tlsSettings
+		second   tlsSettings
+		wantSame bool
+	}{
+		{name: "same settings", first: tlsSettings{}, second: tlsSettings{}, wantSame: true},
+		{name: "same server name", first: tlsSettings{serverName: "api.test"}, second: tlsSettings{serverName: "api.test"}, wantSame: true},
+		{name: "different verify", first: tlsSettings{}, second: tlsSettings{verify: true}},
+		{name: "different server name", first: tlsSettings{serverName: "api.test"}, second: tlsSettings{serverName: "localhost"}},
+		{name: "no reuse", noReuse: true, first: tlsSettings{}, second: tlsSettings{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetClients(t, tt.noReuse)
+
+			first, err := httpClient(tt.first)
+			if err != nil {
+				t.Fatalf("httpClient() error = %v", err)
+			}
+
+			second, err := httpClient(tt.second)
+			if err != nil {
+				t.Fatalf("httpClient() error = %v", err)
+			}
+
+			if same := first == second; same != tt.wantSame {
+				t.Errorf("same client = %v, want %v", same, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	tests := []struct {
+		name            string
+		noReuse         bool
+		wantConnections int32
+	}{
+		{name: "reuse", wantConnections: 1},
+		{name: "no reuse", noReuse: true, wantConnections: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetClients(t, tt.noReuse)
+
+			// Count the connections the server accepts.
+			connections := &atomic.Int32{}
+
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					connections.Add(1)
+				}
+			}
+
+			server.Start()
+			t.Cleanup(server.Close)
+
+			for range 3 {
+				test := &defs.Test{
+					Description: "reuse",
+					Request:     defs.RequestObject{Method: "GET", Endpoint: server.URL},
+					Response:    defs.ResponseObject{Status: http.StatusOK},
+				}
+
+				if err := ExecuteTest(dictionary.New(), test); err != nil {
+					t.Fatalf("ExecuteTest() error = %v", err)
+				}
+			}
+
+			if got := connections.Load(); got != tt.wantConnections {
+				t.Errorf("connections = %d, want %d", got, tt.wantConnections)
+			}
+		})
+	}
+}
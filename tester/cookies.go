@@ -0,0 +1,217 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+	"github.com/tucats/apitest/logging"
+	"gopkg.in/resty.v1"
+)
+
+// These are the scopes of the cookie jars, set with the "COOKIES" dictionary value. Cookies
+// set by a response are sent with the later requests that use the same jar.
+const (
+	// All the tests of the run share one cookie jar.
+	runCookies = "run"
+
+	// The tests in each directory share a cookie jar.
+	directoryCookies = "directory"
+
+	// Each test file has its own cookie jar, so the steps of a scenario share cookies.
+	scenarioCookies = "scenario"
+
+	// No cookies are kept. This is the default.
+	noCookies = "none"
+)
+
+var (
+	jars    = map[string]*cookiejar.Jar{}
+	jarLock sync.Mutex
+)
+
+// cookieJar returns the cookie jar used by a test, based on the "COOKIES" dictionary value,
+// or nil if no cookies are kept. If clear is true, the jar is emptied first. Cookies are
+// opt-in: when "COOKIES" is not set, no jar is used, so existing tests that do not expect
+// cookies to be sent behave as they did before cookie support.
+func cookieJar(dict *dictionary.Dictionary, test *defs.Test, clear bool) (*cookiejar.Jar, error) {
+	var key string
+
+	scope, _ := dict.Get("COOKIES")
+
+	switch strings.ToLower(dictionary.Apply(dict, scope)) {
+	case "", noCookies:
+		return nil, nil
+
+	case runCookies:
+		key = ""
+
+	case directoryCookies:
+		key = filepath.Dir(test.File)
+
+	case scenarioCookies:
+		key = test.File
+
+	default:
+		return nil, fmt.Errorf("invalid COOKIES value '%s'", scope)
+	}
+
+	jarLock.Lock()
+	defer jarLock.Unlock()
+
+	jar, ok := jars[key]
+	if !ok || clear {
+		if clear && logging.Verbose {
+			fmt.Println("  Clearing cookies")
+		}
+
+		jar, _ = cookiejar.New(nil)
+		jars[key] = jar
+	}
+
+	return jar, nil
+}
+
+// sendCookies adds the cookies in the jar for the URL to the request, along with any cookie
+// header given in the test.
+func sendCookies(r *resty.Request, jar *cookiejar.Jar, u *url.URL) {
+	cookies := jar.Cookies(u)
+	if len(cookies) == 0 {
+		return
+	}
+
+	values := make([]string, 0, len(cookies)+1)
+	if text := r.Header.Get("Cookie"); text != "" {
+		values = append(values, text)
+	}
+
+	for _, cookie := range cookies {
+		values = append(values, cookie.String())
+	}
+
+	r.Header.Set("Cookie", strings.Join(values, "; "))
+}
+
+// validateCookies compares the cookies set by the response with the cookies expected by the
+// test, and returns an error for the first that does not match.
+func validateCookies(dict *dictionary.Dictionary, test *defs.Test, resp *resty.Response) error {
+	if logging.Verbose {
+		fmt.Println("  Validating response cookies")
+	}
+
+	received := map[string]*http.Cookie{}
+	for _, cookie := range resp.Cookies() {
+		received[cookie.Name] = cookie
+	}
+
+	for name, expected := range test.Response.Cookies {
+		if logging.Verbose {
+			fmt.Printf("    Validating %s\n", name)
+		}
+
+		cookie, ok := received[name]
+		if !ok {
+			return fmt.Errorf("cookie: expected cookie '%s' to be set", name)
+		}
+
+		if err := validateCookie(dict, cookie, expected); err != nil {
+			return fmt.Errorf("cookie %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateCookie compares the attributes of a cookie with the expected attributes.
+func validateCookie(dict *dictionary.Dictionary, cookie *http.Cookie, expected defs.CookieObject) error {
+	if expected.Value != "" {
+		if value := dictionary.Apply(dict, expected.Value); cookie.Value != value {
+			return fmt.Errorf("expected value '%s', got '%s'", value, cookie.Value)
+		}
+	}
+
+	if expected.Secure != nil && cookie.Secure != *expected.Secure {
+		return fmt.Errorf("expected Secure to be %t", *expected.Secure)
+	}
+
+	if expected.HTTPOnly != nil && cookie.HttpOnly != *expected.HTTPOnly {
+		return fmt.Errorf("expected HttpOnly to be %t", *expected.HTTPOnly)
+	}
+
+	if expected.SameSite != "" {
+		if sameSite := sameSiteName(cookie.SameSite); !strings.EqualFold(sameSite, expected.SameSite) {
+			return fmt.Errorf("expected SameSite to be '%s', got '%s'", expected.SameSite, sameSite)
+		}
+	}
+
+	if expected.Path != "" && cookie.Path != expected.Path {
+		return fmt.Errorf("expected path '%s', got '%s'", expected.Path, cookie.Path)
+	}
+
+	if expected.Domain != "" && cookie.Domain != expected.Domain {
+		return fmt.Errorf("expected domain '%s', got '%s'", expected.Domain, cookie.Domain)
+	}
+
+	expires := cookieExpiry(cookie)
+
+	if expected.Session != nil && expires.IsZero() != *expected.Session {
+		if *expected.Session {
+			return fmt.Errorf("expected a session cookie, but it expires %s", expires.Format(time.RFC3339))
+		}
+
+		return fmt.Errorf("expected an expiry time, but it is a session cookie")
+	}
+
+	if expected.ValidFor != "" {
+		duration, err := time.ParseDuration(dictionary.Apply(dict, expected.ValidFor))
+		if err != nil {
+			return fmt.Errorf("invalid validFor duration '%s': %v", expected.ValidFor, err)
+		}
+
+		if !expires.IsZero() && time.Now().Add(duration).After(expires) {
+			return fmt.Errorf("expected to be valid for %v, but it expires %s", duration, expires.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+// cookieExpiry returns the time a cookie expires, or the zero time for a session cookie. The
+// Max-Age attribute takes precedence over the Expires attribute.
+func cookieExpiry(cookie *http.Cookie) time.Time {
+	switch {
+	case cookie.MaxAge > 0:
+		return time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+
+	case cookie.MaxAge < 0:
+		return time.Unix(0, 0)
+
+	default:
+		return cookie.Expires
+	}
+}
+
+// sameSiteName returns the name of the SameSite attribute of a cookie, or an empty string if
+// the cookie does not have one.
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteStrictMode:
+		return "strict"
+
+	case http.SameSiteLaxMode:
+		return "lax"
+
+	case http.SameSiteNoneMode:
+		return "none"
+
+	default:
+		return ""
+	}
+}
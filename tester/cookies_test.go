@@ -0,0 +1,166 @@
+package tester
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+)
+
+// sessionServer starts a server whose "/login" endpoint sets a session cookie, and whose
+// "/whoami" endpoint responds with 200 if the session cookie is sent and 401 if not.
+func sessionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123", Path: "/", MaxAge: 3600, HttpOnly: true, SameSite: http.SameSiteLaxMode})
+			w.WriteHeader(http.StatusOK)
+
+		case "/whoami":
+			if cookie, err := r.Cookie("session"); err == nil && cookie.Value == "abc123" {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}
+	}))
+
+	t.Cleanup(server.Close)
+
+	// Start each test with no cookie jars.
+	jarLock.Lock()
+	jars = map[string]*cookiejar.Jar{}
+	jarLock.Unlock()
+
+	return server
+}
+
+// cookieRequest makes a GET request to the endpoint as the test in the given file, and
+// returns the status of the response.
+func cookieRequest(t *testing.T, dict *dictionary.Dictionary, file, endpoint string, clear bool) int {
+	t.Helper()
+
+	test := &defs.Test{
+		Description: "cookies",
+		File:        file,
+		Request:     defs.RequestObject{Method: "GET", Endpoint: endpoint, ClearCookies: clear},
+	}
+
+	if err := ExecuteTest(dict, test); err != nil {
+		t.Fatalf("ExecuteTest() error = %v", err)
+	}
+
+	return test.Response.Received
+}
+
+func TestCookieScopes(t *testing.T) {
+	// Each step logs in as the first file, then checks the session as the second file.
+	tests := []struct {
+		name  string
+		scope string
+		login string
+		check string
+		want  int
+	}{
+		{name: "default keeps no cookies", scope: "", login: "a/one.json", check: "a/one.json", want: http.StatusUnauthorized},
+		{name: "none keeps no cookies", scope: "none", login: "a/one.json", check: "a/one.json", want: http.StatusUnauthorized},
+		{name: "run shares across directories", scope: "run", login: "a/one.json", check: "b/two.json", want: http.StatusOK},
+		{name: "directory shares within a directory", scope: "directory", login: "a/one.json", check: "a/two.json", want: http.StatusOK},
+		{name: "directory does not share across directories", scope: "Directory", login: "a/one.json", check: "b/two.json", want: http.StatusUnauthorized},
+		{name: "scenario shares within a file", scope: "scenario", login: "a/one.json", check: "a/one.json", want: http.StatusOK},
+		{name: "scenario does not share across files", scope: "scenario", login: "a/one.json", check: "a/two.json", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := sessionServer(t)
+			root := t.TempDir()
+
+			dict := dictionary.New()
+			if tt.scope != "" {
+				dict.Set("COOKIES", tt.scope)
+			}
+
+			cookieRequest(t, dict, filepath.Join(root, tt.login), server.URL+"/login", false)
+
+			if got := cookieRequest(t, dict, filepath.Join(root, tt.check), server.URL+"/whoami", false); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClearCookies(t *testing.T) {
+	server := sessionServer(t)
+	file := filepath.Join(t.TempDir(), "test.json")
+
+	dict := dictionary.New()
+	dict.Set("COOKIES", "run")
+
+	cookieRequest(t, dict, file, server.URL+"/login", false)
+
+	if got := cookieRequest(t, dict, file, server.URL+"/whoami", true); got != http.StatusUnauthorized {
+		t.Errorf("status after clearing = %d, want %d", got, http.StatusUnauthorized)
+	}
+
+	cookieRequest(t, dict, file, server.URL+"/login", false)
+
+	if got := cookieRequest(t, dict, file, server.URL+"/whoami", false); got != http.StatusOK {
+		t.Errorf("status after logging in again = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestInvalidCookieScope(t *testing.T) {
+	server := sessionServer(t)
+
+	dict := dictionary.New()
+	dict.Set("COOKIES", "everywhere")
+
+	test := &defs.Test{
+		Description: "cookies",
+		Request:     defs.RequestObject{Method: "GET", Endpoint: server.URL + "/login"},
+	}
+
+	if err := ExecuteTest(dict, test); err == nil {
+		t.Errorf("ExecuteTest() error = nil, want an invalid COOKIES value error")
+	}
+}
+
+func TestValidateCookies(t *testing.T) {
+	server := sessionServer(t)
+	yes, no := true, false
+
+	tests := []struct {
+		name    string
+		cookie  defs.CookieObject
+		wantErr bool
+	}{
+		{name: "matching attributes", cookie: defs.CookieObject{Value: "abc123", HTTPOnly: &yes, Secure: &no, SameSite: "Lax", Path: "/", Session: &no, ValidFor: "30m"}},
+		{name: "wrong value", cookie: defs.CookieObject{Value: "xyz"}, wantErr: true},
+		{name: "wrong SameSite", cookie: defs.CookieObject{SameSite: "strict"}, wantErr: true},
+		{name: "not Secure", cookie: defs.CookieObject{Secure: &yes}, wantErr: true},
+		{name: "not a session cookie", cookie: defs.CookieObject{Session: &yes}, wantErr: true},
+		{name: "expires too soon", cookie: defs.CookieObject{ValidFor: "2h"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := &defs.Test{
+				Description: "cookies",
+				Request:     defs.RequestObject{Method: "GET", Endpoint: server.URL + "/login"},
+				Response:    defs.ResponseObject{Cookies: map[string]defs.CookieObject{"session": tt.cookie}},
+			}
+
+			err := ExecuteTest(dictionary.New(), test)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExecuteTest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
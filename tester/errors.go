@@ -0,0 +1,29 @@
+package tester
+
+import (
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/errors"
+)
+
+// AllErrors is true if all the status, header, and body validations of a test are to be
+// performed even after one of them fails, so the test failure lists every mismatch. When
+// false, the test stops at the first mismatch.
+var AllErrors = false
+
+// testFailure forms the error for a test from the list of failures found while validating
+// the response. The failures are chained to an error for the test description, so the text
+// of the error lists the test followed by each of the failures. If there are no failures,
+// the result is nil.
+func testFailure(test *defs.Test, failures []error) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	chain := errors.New(test.Description)
+
+	for _, failure := range failures {
+		chain = errors.New(failure.Error()).Chain(chain)
+	}
+
+	return chain
+}
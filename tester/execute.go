@@ -1,9 +1,9 @@
 package tester
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,28 +15,177 @@ import (
 	"gopkg.in/resty.v1"
 )
 
-func ExecuteTest(test *defs.Test) error {
+func ExecuteTest(dict *dictionary.Dictionary, test *defs.Test) error {
 	var (
 		err  error
 		kind contentType = unknownContent
 	)
 
+	// Keep any expected body in the test for comparison, since making the request replaces
+	// it with the actual response body.
+	expected := test.Response.Body
+
+	resp, err := sendRequest(dict, test)
+	if err != nil {
+		return err
+	}
+
+	// If the test waits for a condition, make the request again until the condition is met.
+	if test.WaitUntil != nil {
+		resp, err = waitUntil(dict, test, resp)
+		if err != nil {
+			return err
+		}
+	}
+
+	b := resp.Body()
+
+	// Verify that the response status code matches the expected status code
+	var failures []error
+
+	if test.Response.Status > 0 {
+		if logging.Verbose {
+			fmt.Printf("  Validating response code %d\n", test.Response.Status)
+		}
+
+		if resp.StatusCode() != test.Response.Status {
+			failures = append(failures, fmt.Errorf("expected status %d, got %d", test.Response.Status, resp.StatusCode()))
+			if !AllErrors {
+				return testFailure(test, failures)
+			}
+		}
+	}
+
+	// Validate any headers in the response specifications.
+	if len(test.Response.Headers) > 0 {
+		if logging.Verbose {
+			fmt.Println("  Validating response headers")
+		}
+
+		for key, values := range test.Response.Headers {
+			for _, value := range values {
+				if logging.Verbose {
+					fmt.Printf("    Validating %s\n", key)
+				}
+
+				value = dictionary.Apply(dict, value)
+
+				actual, ok := resp.Header()[key]
+				if !ok {
+					failures = append(failures, fmt.Errorf("expected header '%s' to be present", key))
+				} else if !strings.Contains(strings.Join(actual, ","), value) {
+					failures = append(failures, fmt.Errorf("expected header '%s' to contain '%s', got '%s'", key, value, strings.Join(actual, ",")))
+				}
+
+				if len(failures) > 0 && !AllErrors {
+					return testFailure(test, failures)
+				}
+			}
+		}
+	}
+
+	// Validate the TLS connection used for the request, if there are expectations for it.
+	if test.Response.TLS != nil && (AllErrors || len(failures) == 0) {
+		if err := validateTLS(dict, test, resp); err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	// Validate the cookies set by the response, if there are expectations for them.
+	if len(test.Response.Cookies) > 0 && (AllErrors || len(failures) == 0) {
+		if err := validateCookies(dict, test, resp); err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	// Validate the response body if present
+	if len(b) > 0 {
+		kind = unknownContent
+
+		for key, value := range resp.Header() {
+			if strings.EqualFold(key, "content-type") {
+				v := strings.ToLower(strings.Join(value, ","))
+				if strings.Contains(v, "json") {
+					kind = jsonContent
+				} else if strings.Contains(v, "text") {
+					kind = textContent
+				}
+			}
+		}
+
+		restLog("Response body", b, kind)
+
+		if AllErrors || len(failures) == 0 {
+			failures = append(failures, validateTest(dict, test)...)
+		}
+	}
+
+	// Compare the response body to the expected body, if any.
+	if expected != "" && (AllErrors || len(failures) == 0) {
+		if err := validateBody(dict, expected, test); err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	// Validate the response body against the schema, if any.
+	if test.Response.Schema != nil && (AllErrors || len(failures) == 0) {
+		if err := validateSchema(dict, test); err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	// Validate the response body against the type definition, if any.
+	if test.Response.ResponseType != nil && (AllErrors || len(failures) == 0) {
+		if err := validateResponseType(test); err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	// Compare the response body to the snapshot, if any.
+	if test.Response.Snapshot != nil && (AllErrors || len(failures) == 0) {
+		if err := validateSnapshot(dict, test); err != nil {
+			failures = append(failures, err)
+		}
+	}
+
+	err = testFailure(test, failures)
+
+	// If there were no errors, execute any tasks in the test.
+	if err == nil {
+		for _, task := range test.Tasks {
+			err = executeTask(dict, task)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		fmt.Println("DEBUG: tasks not executed due to previous errors, ", err)
+	}
+
+	return err
+}
+
+// sendRequest makes the HTTP request for the test, after applying the dictionary to the
+// request. The time, duration, status, and body of the response are recorded in the test.
+func sendRequest(dict *dictionary.Dictionary, test *defs.Test) (*resty.Response, error) {
+	var kind contentType = unknownContent
+
 	// Form the URL string. If the endpoint starts with a slash, assume we should fetch
 	// the default scheme, host, and port and add them to the URL string.
 	urlString := test.Request.Endpoint
 
 	if strings.HasPrefix(test.Request.Endpoint, "/") {
-		if port := dictionary.Dictionary["PORT"]; port != "" {
+		if port, _ := dict.Get("PORT"); port != "" {
 			urlString = ":" + port + urlString
 		}
 
-		if host := dictionary.Dictionary["HOST"]; host != "" {
+		if host, _ := dict.Get("HOST"); host != "" {
 			urlString = host + urlString
 		} else {
 			urlString = "localhost" + urlString
 		}
 
-		if scheme := dictionary.Dictionary["SCHEME"]; scheme != "" {
+		if scheme, _ := dict.Get("SCHEME"); scheme != "" {
 			urlString = scheme + "://" + urlString
 		} else {
 			urlString = "https://" + urlString
@@ -56,17 +205,23 @@ func ExecuteTest(test *defs.Test) error {
 		fmt.Printf("  %s %s\n", test.Request.Method, urlString)
 	}
 
-	// Create an HTTP client
-	client := resty.New()
-	tlsConfiguration := &tls.Config{InsecureSkipVerify: true}
-	client.SetTLSClientConfig(tlsConfiguration)
+	// Get the client for the TLS settings of the request.
+	settings, err := requestTLS(dict, test)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := httpClient(settings)
+	if err != nil {
+		return nil, err
+	}
 
 	r := client.NewRequest()
 
 	// Update the body, headers and URLstring with the dictionary values
 	for key, values := range test.Request.Headers {
 		for _, value := range values {
-			value = dictionary.Apply(value)
+			value = dictionary.Apply(dict, value)
 
 			r.Header.Add(key, value)
 
@@ -81,20 +236,20 @@ func ExecuteTest(test *defs.Test) error {
 		}
 	}
 
-	urlString = dictionary.Apply(urlString)
+	urlString = dictionary.Apply(dict, urlString)
 
 	// If the request body is a file specification, substitute that now.
 	if test.Request.File != "" {
-		test.Request.File = dictionary.Apply(test.Request.File)
+		test.Request.File = dictionary.Apply(dict, test.Request.File)
 
 		path, err := filepath.Abs(test.Request.File)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		data, err := os.ReadFile(path)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		test.Request.Body = string(data)
@@ -123,7 +278,7 @@ func ExecuteTest(test *defs.Test) error {
 	case []interface{}:
 		b, err := json.Marshal(actual)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		body = string(b)
@@ -131,103 +286,80 @@ func ExecuteTest(test *defs.Test) error {
 	case map[string]interface{}:
 		b, err := json.Marshal(actual)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		body = string(b)
 
 	default:
-		return fmt.Errorf("Unexpected body type: %T", actual)
+		return nil, fmt.Errorf("Unexpected body type: %T", actual)
 	}
 
 	if len(body) > 0 {
-		b := []byte(dictionary.Apply(body))
+		b := []byte(dictionary.Apply(dict, body))
 		r.Body = b
 
+		// Keep the request body actually sent, so it is available for reporting.
+		test.Request.Body = string(b)
+
 		restLog("Request body", b, kind)
 	}
 
-	// Make the HTTP request
-	now := time.Now()
-
-	resp, err := r.Execute(test.Request.Method, urlString)
+	// Send the cookies set by the responses to earlier requests that use the same jar.
+	jar, err := cookieJar(dict, test, test.Request.ClearCookies)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	test.Duration = time.Since(now)
+	var u *url.URL
 
-	// Verify that the response status code matches the expected status code
-	if test.Response.Status > 0 {
-		if logging.Verbose {
-			fmt.Printf("  Validating response code %d\n", test.Response.Status)
+	if jar != nil {
+		u, err = url.Parse(urlString)
+		if err != nil {
+			return nil, err
 		}
 
-		if resp.StatusCode() != test.Response.Status {
-			return fmt.Errorf("%s, expected status %d, got %d", test.Description, test.Response.Status, resp.StatusCode())
-		}
+		sendCookies(r, jar, u)
 	}
 
-	// Validate any headers in the response specifications.
-	if len(test.Response.Headers) > 0 {
-		if logging.Verbose {
-			fmt.Println("  Validating response headers")
-		}
+	// Limit the time the request can take to the timeout of the test and the deadline of
+	// the test run.
+	ctx, cancel, timeout, err := requestContext(dict, test)
+	if err != nil {
+		return nil, err
+	}
 
-		for key, values := range test.Response.Headers {
-			for _, value := range values {
-				if logging.Verbose {
-					fmt.Printf("    Validating %s\n", key)
-				}
+	defer cancel()
 
-				value = dictionary.Apply(value)
+	r.SetContext(ctx)
 
-				actual, ok := resp.Header()[key]
-				if !ok {
-					return fmt.Errorf("%s, expected header '%s' to be present", test.Description, key)
-				}
+	// Make the HTTP request
+	now := time.Now()
+	test.Time = now
+	test.TimedOut = false
 
-				if !strings.Contains(strings.Join(actual, ","), value) {
-					return fmt.Errorf("%s, expected header '%s' to contain '%s', got '%s'", test.Description, key, value, strings.Join(actual, ","))
-				}
-			}
-		}
+	resp, err := r.Execute(test.Request.Method, urlString)
+	if err != nil {
+		return nil, requestError(ctx, test, timeout, err)
 	}
 
-	// Validate the response body if present
-	b := resp.Body()
-	if len(b) > 0 {
-		test.Response.Body = string(b)
-
-		kind = unknownContent
-
-		for key, value := range resp.Header() {
-			if strings.EqualFold(key, "content-type") {
-				v := strings.ToLower(strings.Join(value, ","))
-				if strings.Contains(v, "json") {
-					kind = jsonContent
-				} else if strings.Contains(v, "text") {
-					kind = textContent
-				}
-			}
-		}
+	test.Duration = time.Since(now)
+	test.Response.Received = resp.StatusCode()
+	test.Response.ReceivedHeaders = resp.Header()
 
-		restLog("Response body", b, kind)
+	// Keep the response body, so it is available for reporting even if the test fails.
+	test.Response.Body = string(resp.Body())
 
-		err = validateTest(test)
+	// Keep the cookies set by the response, so their values can be saved, and add them to
+	// the jar for later requests.
+	test.Response.ReceivedCookies = map[string]string{}
+	for _, cookie := range resp.Cookies() {
+		test.Response.ReceivedCookies[cookie.Name] = cookie.Value
 	}
 
-	// If there were no errors, execute any tasks in the test.
-	if err == nil {
-		for _, task := range test.Tasks {
-			err = executeTask(task)
-			if err != nil {
-				return err
-			}
-		}
-	} else {
-		fmt.Println("DEBUG: tasks not executed due to previous errors, ", err)
+	if jar != nil {
+		jar.SetCookies(u, resp.Cookies())
 	}
 
-	return err
+	return resp, nil
 }
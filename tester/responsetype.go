@@ -0,0 +1,135 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/logging"
+	"github.com/tucats/apitest/parser"
+	"github.com/tucats/validator"
+)
+
+// validateResponseType checks the response body of the test against the type definition in
+// the response specification, using the same validator used for the test files themselves.
+func validateResponseType(test *defs.Test) error {
+	if logging.Verbose {
+		fmt.Println("  Validating response type")
+	}
+
+	item, err := typeItem("", test.Response.ResponseType)
+	if err != nil {
+		return fmt.Errorf("responseType: %v", err)
+	}
+
+	if err := item.Validate(test.Response.Body); err != nil {
+		return fmt.Errorf("responseType: %v", err)
+	}
+
+	var body interface{}
+	if err := json.Unmarshal([]byte(test.Response.Body), &body); err != nil {
+		return fmt.Errorf("responseType: response body is not valid JSON: %v", err)
+	}
+
+	if err := checkStrings(item, body); err != nil {
+		return fmt.Errorf("responseType: %v", err)
+	}
+
+	return nil
+}
+
+// checkStrings checks that each value with a "type=string" definition is a JSON string. The
+// validator accepts a number for a string, since it converts the number to its text, so this
+// is checked separately.
+func checkStrings(item *validator.Item, value interface{}) error {
+	switch item.ItemType {
+	case validator.TypeString:
+		if _, ok := value.(float64); ok {
+			name := item.Name
+			if name == "" {
+				name = "response body"
+			}
+
+			return fmt.Errorf("%s: expected a string, got %s", name, parser.TypeName(value))
+		}
+
+	case validator.TypeStruct:
+		if fields, ok := value.(map[string]interface{}); ok {
+			for _, field := range item.Fields {
+				if v, found := fields[field.Name]; found {
+					if err := checkStrings(field, v); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+	case validator.TypeArray:
+		if elements, ok := value.([]interface{}); ok && item.BaseType != nil {
+			for _, element := range elements {
+				if err := checkStrings(item.BaseType, element); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// typeItem creates the validator item for a type definition. A string is a validator tag for
+// a single value, an object defines the fields of an object, and an array with one element
+// defines the type of the elements of an array. Values without a "type" tag may be of any
+// type, and objects may contain fields that are not in the definition.
+func typeItem(name string, definition interface{}) (*validator.Item, error) {
+	switch actual := definition.(type) {
+	case string:
+		item := validator.NewType(validator.TypeAny).SetName(name)
+		if err := item.ParseTag(actual); err != nil {
+			return nil, err
+		}
+
+		return item, nil
+
+	case map[string]interface{}:
+		item := validator.NewType(validator.TypeStruct).SetName(name).SetForeignKeys(true)
+
+		// Add the fields in a predictable order, so the first error reported is consistent.
+		keys := make([]string, 0, len(actual))
+		for key := range actual {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			field, err := typeItem(key, actual[key])
+			if err != nil {
+				return nil, err
+			}
+
+			item.AddField(*field)
+		}
+
+		return item, nil
+
+	case []interface{}:
+		if len(actual) != 1 {
+			return nil, fmt.Errorf("array type for '%s' must have exactly one element", name)
+		}
+
+		base, err := typeItem(name, actual[0])
+		if err != nil {
+			return nil, err
+		}
+
+		item := validator.NewType(validator.TypeArray).SetName(name)
+		item.BaseType = base
+
+		return item, nil
+
+	default:
+		return nil, fmt.Errorf("invalid type definition for '%s': %v", name, definition)
+	}
+}
@@ -0,0 +1,65 @@
+package tester
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/tucats/apitest/defs"
+)
+
+func TestValidateResponseType(t *testing.T) {
+	user := `{
+		"id": "type=integer,required,min=1",
+		"name": "type=string,required",
+		"address": { "city": "type=string,required" },
+		"tags": ["type=string"]
+	}`
+
+	tests := []struct {
+		name       string
+		definition string
+		body       string
+		wantErr    string
+	}{
+		{name: "matching object", definition: user, body: `{"id": 7, "name": "Alice", "address": {"city": "Paris"}, "tags": ["a", "b"]}`},
+		{name: "optional fields missing", definition: user, body: `{"id": 7, "name": "Alice"}`},
+		{name: "extra fields", definition: user, body: `{"id": 7, "name": "Alice", "role": "admin"}`},
+		{name: "missing required field", definition: user, body: `{"id": 7}`, wantErr: "name"},
+		{name: "value out of range", definition: user, body: `{"id": 0, "name": "Alice"}`, wantErr: "id"},
+		{name: "nested object", definition: user, body: `{"id": 7, "name": "Alice", "address": {"zip": "75001"}}`, wantErr: "city"},
+		{name: "nested field with the wrong type", definition: user, body: `{"id": 7, "name": "Alice", "address": {"city": 75}}`, wantErr: "city: expected a string, got number"},
+		{name: "number for a string", definition: user, body: `{"id": 7, "name": 42}`, wantErr: "name: expected a string, got number"},
+		{name: "boolean for a string", definition: user, body: `{"id": 7, "name": true}`, wantErr: "name"},
+		{name: "array element with the wrong type", definition: user, body: `{"id": 7, "name": "Alice", "tags": ["a", 2]}`, wantErr: "expected a string, got number"},
+		{name: "array of objects", definition: `[{"id": "type=integer,required"}]`, body: `[{"id": 1}, {"id": 2}]`},
+		{name: "array of objects missing a field", definition: `[{"id": "type=integer,required"}]`, body: `[{"id": 1}, {}]`, wantErr: "id"},
+		{name: "single value", definition: `"type=string,minlength=2"`, body: `"ok"`},
+		{name: "single value for a string", definition: `"type=string"`, body: `7`, wantErr: "response body: expected a string, got number"},
+		{name: "array with no elements", definition: `{"items": []}`, body: `{"items": []}`, wantErr: "exactly one element"},
+		{name: "array with two elements", definition: `{"items": ["type=string", "type=integer"]}`, body: `{"items": []}`, wantErr: "exactly one element"},
+		{name: "invalid definition", definition: `{"id": 7}`, body: `{"id": 7}`, wantErr: "invalid type definition for 'id'"},
+		{name: "invalid tag", definition: `{"id": "type=date"}`, body: `{"id": 7}`, wantErr: "responseType"},
+		{name: "body is not JSON", definition: user, body: `not json`, wantErr: "responseType"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var definition interface{}
+			if err := json.Unmarshal([]byte(tt.definition), &definition); err != nil {
+				t.Fatalf("invalid definition, %v", err)
+			}
+
+			test := &defs.Test{Response: defs.ResponseObject{Body: tt.body, ResponseType: definition}}
+
+			err := validateResponseType(test)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("validateResponseType() error = %v, want nil", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validateResponseType() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package tester
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+	"github.com/tucats/apitest/logging"
+)
+
+// ExecuteWithRetry executes the test, retrying it as described by the Retry object of the
+// test if it fails. The number of attempts made is recorded in the test.
+func ExecuteWithRetry(dict *dictionary.Dictionary, test *defs.Test) error {
+	retry := test.Retry
+	if retry == nil || retry.Attempts <= 1 {
+		test.Attempts = 1
+
+		return ExecuteTest(dict, test)
+	}
+
+	delay, err := retryDelay(dict, retry)
+	if err != nil {
+		return err
+	}
+
+	for _, kind := range retry.On {
+		if kind != "connection" && kind != "status" && kind != "validation" {
+			return fmt.Errorf("invalid retry kind '%s'", kind)
+		}
+	}
+
+	// Executing a test replaces the expected response body with the actual one, so keep
+	// the expected body to restore before each attempt.
+	expected := test.Response.Body
+
+	for attempt := 1; ; attempt++ {
+		test.Attempts = attempt
+		test.Response.Body = expected
+		test.Response.Received = 0
+		test.Results = nil
+		test.Time = time.Time{}
+
+		err = ExecuteTest(dict, test)
+		if err == nil || attempt >= retry.Attempts || !isRetryable(test) {
+			return err
+		}
+
+		if logging.Verbose {
+			fmt.Printf("  Attempt %d of %d failed, retrying in %v: %v\n", attempt, retry.Attempts, delay, err)
+		}
+
+		// Stop retrying if the deadline of the test run passes while waiting.
+		if !sleep(delay) {
+			return err
+		}
+
+		if retry.Backoff > 0 {
+			delay = time.Duration(float64(delay) * retry.Backoff)
+		}
+	}
+}
+
+// retryDelay returns the delay before the second attempt of a test.
+func retryDelay(dict *dictionary.Dictionary, retry *defs.Retry) (time.Duration, error) {
+	if retry.Delay == "" {
+		return 0, nil
+	}
+
+	delay, err := time.ParseDuration(dictionary.Apply(dict, retry.Delay))
+	if err != nil {
+		return 0, fmt.Errorf("invalid retry delay '%s': %v", retry.Delay, err)
+	}
+
+	return delay, nil
+}
+
+// isRetryable determines if the failure of a test is one of the kinds of failure that the
+// test retries.
+func isRetryable(test *defs.Test) bool {
+	// If the request was never sent, the test itself is in error, such as an invalid
+	// timeout or request file, and would fail the same way on every attempt.
+	if test.Time.IsZero() {
+		return false
+	}
+
+	retry := test.Retry
+	if len(retry.On) == 0 {
+		return true
+	}
+
+	status := test.Response.Received
+
+	// If no response was received, the request failed to connect.
+	if status == 0 {
+		return slices.Contains(retry.On, "connection")
+	}
+
+	if slices.Contains(retry.On, "status") {
+		if len(retry.Status) > 0 && slices.Contains(retry.Status, status) {
+			return true
+		}
+
+		if len(retry.Status) == 0 && test.Response.Status > 0 && status != test.Response.Status {
+			return true
+		}
+	}
+
+	return slices.Contains(retry.On, "validation")
+}
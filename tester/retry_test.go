@@ -0,0 +1,195 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+)
+
+// countServer starts a server that responds to each request with the next status in the list,
+// repeating the last one once the list runs out. The body is a JSON object with the number of
+// requests made so far, and the counter is returned with the server.
+func countServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	count := &atomic.Int32{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(count.Add(1))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+		fmt.Fprintf(w, `{"count": %d}`, n)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, count
+}
+
+func TestExecuteWithRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retry        defs.Retry
+		tests        []defs.Validation
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "single attempt", statuses: []int{500, 200}, retry: defs.Retry{Attempts: 1}, wantAttempts: 1, wantErr: true},
+		{name: "retries until passing", statuses: []int{503, 503, 200}, retry: defs.Retry{Attempts: 5}, wantAttempts: 3},
+		{name: "stops after the last attempt", statuses: []int{500}, retry: defs.Retry{Attempts: 3}, wantAttempts: 3, wantErr: true},
+		{name: "status in the list", statuses: []int{503, 200}, retry: defs.Retry{Attempts: 3, On: []string{"status"}, Status: []int{503}}, wantAttempts: 2},
+		{name: "status not in the list", statuses: []int{500, 200}, retry: defs.Retry{Attempts: 3, On: []string{"status"}, Status: []int{503}}, wantAttempts: 1, wantErr: true},
+		{name: "any unexpected status", statuses: []int{500, 200}, retry: defs.Retry{Attempts: 3, On: []string{"status"}}, wantAttempts: 2},
+		{name: "connection does not retry a status", statuses: []int{503, 200}, retry: defs.Retry{Attempts: 3, On: []string{"connection"}}, wantAttempts: 1, wantErr: true},
+		{
+			name:         "validation",
+			statuses:     []int{200},
+			retry:        defs.Retry{Attempts: 3, On: []string{"validation"}},
+			tests:        []defs.Validation{{Name: "count", Expression: "count", Operator: "ge", Value: "2"}},
+			wantAttempts: 2,
+		},
+		{
+			name:         "status does not retry a validation",
+			statuses:     []int{200},
+			retry:        defs.Retry{Attempts: 3, On: []string{"status"}},
+			tests:        []defs.Validation{{Name: "count", Expression: "count", Operator: "ge", Value: "2"}},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, count := countServer(t, tt.statuses...)
+
+			test := &defs.Test{
+				Description: "retry",
+				Request:     defs.RequestObject{Method: "GET", Endpoint: server.URL},
+				Response:    defs.ResponseObject{Status: http.StatusOK},
+				Tests:       tt.tests,
+				Retry:       &tt.retry,
+			}
+
+			err := ExecuteWithRetry(dictionary.New(), test)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExecuteWithRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if test.Attempts != tt.wantAttempts {
+				t.Errorf("Attempts = %d, want %d", test.Attempts, tt.wantAttempts)
+			}
+
+			if got := int(count.Load()); got != tt.wantAttempts {
+				t.Errorf("requests = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryConnection(t *testing.T) {
+	// A server that has been closed refuses the connection.
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	tests := []struct {
+		name         string
+		on           []string
+		wantAttempts int
+	}{
+		{name: "connection", on: []string{"connection"}, wantAttempts: 3},
+		{name: "every failure", wantAttempts: 3},
+		{name: "status", on: []string{"status"}, wantAttempts: 1},
+		{name: "validation", on: []string{"validation"}, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := &defs.Test{
+				Description: "retry",
+				Request:     defs.RequestObject{Method: "GET", Endpoint: server.URL},
+				Retry:       &defs.Retry{Attempts: 3, On: tt.on},
+			}
+
+			if err := ExecuteWithRetry(dictionary.New(), test); err == nil {
+				t.Errorf("ExecuteWithRetry() error = nil, want a connection error")
+			}
+
+			if test.Attempts != tt.wantAttempts {
+				t.Errorf("Attempts = %d, want %d", test.Attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryDeadline(t *testing.T) {
+	server, count := countServer(t, http.StatusInternalServerError)
+
+	Deadline = time.Now().Add(50 * time.Millisecond)
+	t.Cleanup(func() { Deadline = time.Time{} })
+
+	test := &defs.Test{
+		Description: "retry",
+		Request:     defs.RequestObject{Method: "GET", Endpoint: server.URL},
+		Response:    defs.ResponseObject{Status: http.StatusOK},
+		Retry:       &defs.Retry{Attempts: 3, Delay: "10s"},
+	}
+
+	start := time.Now()
+
+	if err := ExecuteWithRetry(dictionary.New(), test); err == nil {
+		t.Errorf("ExecuteWithRetry() error = nil, want a status error")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ExecuteWithRetry() took %v, want it to stop at the deadline", elapsed)
+	}
+
+	if got := count.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestRetryInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   defs.Retry
+		timeout string
+	}{
+		{name: "invalid kind", retry: defs.Retry{Attempts: 2, On: []string{"timeout"}}},
+		{name: "invalid delay", retry: defs.Retry{Attempts: 2, Delay: "soon"}},
+		{name: "invalid request timeout", retry: defs.Retry{Attempts: 3, On: []string{"connection"}}, timeout: "soon"},
+		{name: "invalid request timeout for every failure", retry: defs.Retry{Attempts: 3}, timeout: "soon"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, count := countServer(t, http.StatusOK)
+
+			test := &defs.Test{
+				Description: "retry",
+				Request:     defs.RequestObject{Method: "GET", Endpoint: server.URL, Timeout: tt.timeout},
+				Retry:       &tt.retry,
+			}
+
+			if err := ExecuteWithRetry(dictionary.New(), test); err == nil {
+				t.Errorf("ExecuteWithRetry() error = nil, want an error")
+			}
+
+			if test.Attempts > 1 {
+				t.Errorf("Attempts = %d, want at most 1", test.Attempts)
+			}
+
+			if got := count.Load(); got != 0 {
+				t.Errorf("requests = %d, want 0", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+	"github.com/tucats/apitest/logging"
+	"github.com/tucats/apitest/parser"
+	"github.com/tucats/apitest/schema"
+)
+
+// validateSchema checks the response body of the test against the JSON Schema in the
+// response specification. The error lists every violation of the schema found.
+func validateSchema(dict *dictionary.Dictionary, test *defs.Test) error {
+	var (
+		body      interface{}
+		validator *schema.Validator
+		err       error
+	)
+
+	if logging.Verbose {
+		fmt.Println("  Validating response schema")
+	}
+
+	// Schema files have comments removed and dictionary substitutions applied, the same
+	// as test files.
+	reader := func(path string) ([]byte, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(dictionary.Apply(dict, string(parser.RemoveComments(b)))), nil
+	}
+
+	root := schemaRoot(dict)
+
+	if path, ok := test.Response.Schema.(string); ok {
+		validator, err = schema.Load(schemaPath(dict, test, path), root, reader)
+		if err != nil {
+			return fmt.Errorf("schema: %v", err)
+		}
+	} else {
+		// References in a schema given in the test are relative to the directory of the test
+		// file, the same as the path of a schema file.
+		validator = schema.New(test.Response.Schema, filepath.Dir(test.File), root, reader)
+	}
+
+	if err := json.Unmarshal([]byte(test.Response.Body), &body); err != nil {
+		return fmt.Errorf("schema: response body is not valid JSON: %v", err)
+	}
+
+	violations := validator.Validate(body)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		messages[i] = violation.Error()
+	}
+
+	return fmt.Errorf("schema: %s", strings.Join(messages, ", "))
+}
+
+// schemaPath returns the path of the schema file for the test. A relative path is relative to
+// the directory of the test file.
+func schemaPath(dict *dictionary.Dictionary, test *defs.Test, path string) string {
+	path = dictionary.Apply(dict, path)
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(filepath.Dir(test.File), path)
+}
+
+// schemaRoot returns the directory that schema files referenced by "$ref" must be within.
+// This is the "SCHEMA_ROOT" dictionary value if present, otherwise the directory of the
+// test suite being run.
+func schemaRoot(dict *dictionary.Dictionary) string {
+	if root, ok := dict.Get("SCHEMA_ROOT"); ok {
+		return root
+	}
+
+	root, _ := dict.Get("ROOT")
+	if info, err := os.Stat(root); err == nil && !info.IsDir() {
+		root = filepath.Dir(root)
+	}
+
+	return root
+}
@@ -0,0 +1,69 @@
+package tester
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+)
+
+func TestValidateSchema(t *testing.T) {
+	// The test suite has a test directory containing a user schema, and a schema outside of
+	// the suite that must not be read.
+	base := t.TempDir()
+	root := filepath.Join(base, "suite")
+	dir := filepath.Join(root, "users")
+
+	files := map[string]string{
+		filepath.Join(dir, "user.schema"):     `{ "type": "object", "required": ["id"], "properties": { "id": { "type": "integer" } } }`,
+		filepath.Join(dir, "list.schema"):     `{ "type": "array", "items": { "$ref": "user.schema" } }`,
+		filepath.Join(base, "outside.schema"): `{ "type": "object" }`,
+	}
+
+	for path, text := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		schema  interface{}
+		body    string
+		wantErr bool
+	}{
+		{name: "inline", schema: map[string]interface{}{"type": "object"}, body: `{"id": 1}`},
+		{name: "inline mismatch", schema: map[string]interface{}{"type": "array"}, body: `{"id": 1}`, wantErr: true},
+		{name: "inline relative reference", schema: map[string]interface{}{"$ref": "user.schema"}, body: `{"id": 1}`},
+		{name: "inline relative reference mismatch", schema: map[string]interface{}{"$ref": "user.schema"}, body: `{"id": "a"}`, wantErr: true},
+		{name: "inline reference outside the root", schema: map[string]interface{}{"$ref": "../../outside.schema"}, body: `{"id": 1}`, wantErr: true},
+		{name: "file", schema: "user.schema", body: `{"id": 1}`},
+		{name: "file with a relative reference", schema: "list.schema", body: `[{"id": 1}, {"id": 2}]`},
+		{name: "file with a relative reference mismatch", schema: "list.schema", body: `[{"id": 1}, {}]`, wantErr: true},
+		{name: "file from the dictionary", schema: "{{ROOT}}/users/user.schema", body: `{"id": 1}`},
+		{name: "file outside the root", schema: "../../outside.schema", body: `{"id": 1}`, wantErr: true},
+		{name: "missing file", schema: "missing.schema", body: `{"id": 1}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dict := dictionary.New()
+			dict.Set("ROOT", root)
+
+			test := &defs.Test{
+				File:     filepath.Join(dir, "get.json"),
+				Response: defs.ResponseObject{Body: tt.body, Schema: tt.schema},
+			}
+
+			if err := validateSchema(dict, test); (err != nil) != tt.wantErr {
+				t.Errorf("validateSchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
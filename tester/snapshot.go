@@ -0,0 +1,121 @@
+package tester
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+	"github.com/tucats/apitest/diff"
+	"github.com/tucats/apitest/logging"
+)
+
+// UpdateSnapshots is true if the snapshot files of the tests are to be rewritten with the
+// actual response bodies, instead of being compared to them.
+var UpdateSnapshots = false
+
+// validateSnapshot compares the response body of the test to the body stored in the snapshot
+// file. When the body is JSON, the error lists each difference between the two. If the
+// UpdateSnapshots flag is set, the snapshot file is written from the response body instead.
+func validateSnapshot(dict *dictionary.Dictionary, test *defs.Test) error {
+	path := snapshotPath(dict, test)
+
+	actual, isJSON := normalize(test.Response.Body)
+
+	if UpdateSnapshots {
+		if logging.Verbose {
+			fmt.Printf("  Updating snapshot %s\n", path)
+		}
+
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			return fmt.Errorf("snapshot: %v", err)
+		}
+
+		return nil
+	}
+
+	if logging.Verbose {
+		fmt.Printf("  Validating snapshot %s\n", path)
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("snapshot: %s does not exist, use --update-snapshots to create it", filepath.Base(path))
+	} else if err != nil {
+		return fmt.Errorf("snapshot: %v", err)
+	}
+
+	// Compare the bodies as JSON if both are valid JSON, otherwise as text.
+	var expectedValue, actualValue interface{}
+
+	if isJSON && json.Unmarshal(b, &expectedValue) == nil {
+		_ = json.Unmarshal([]byte(actual), &actualValue)
+
+		differences := diff.Compare(expectedValue, actualValue, test.Response.Snapshot.Ignore)
+		if len(differences) > 0 {
+			return fmt.Errorf("snapshot: response body does not match %s\n%s", filepath.Base(path), indent(diff.Format(differences)))
+		}
+
+		return nil
+	}
+
+	if strings.TrimSpace(string(b)) != strings.TrimSpace(actual) {
+		return fmt.Errorf("snapshot: response body does not match %s", filepath.Base(path))
+	}
+
+	return nil
+}
+
+// snapshotPath returns the path of the snapshot file for the test. This is relative to the
+// directory of the test file, and defaults to the test file name with a ".snapshot" extension.
+// The default for a step of a scenario also includes the step number, such as ".step2.snapshot",
+// so each step has its own snapshot file.
+func snapshotPath(dict *dictionary.Dictionary, test *defs.Test) string {
+	file := dictionary.Apply(dict, test.Response.Snapshot.File)
+	if file == "" {
+		file = strings.TrimSuffix(filepath.Base(test.File), filepath.Ext(test.File))
+		if test.Step > 0 {
+			file += fmt.Sprintf(".step%d", test.Step)
+		}
+
+		file += ".snapshot"
+	}
+
+	if filepath.IsAbs(file) {
+		return file
+	}
+
+	return filepath.Join(filepath.Dir(test.File), file)
+}
+
+// normalize formats a response body that is JSON with consistent indentation and object
+// keys in sorted order, so snapshot files are readable and stable. The second result is
+// false if the body is not JSON, in which case it is returned unchanged.
+func normalize(body string) (string, bool) {
+	var value interface{}
+
+	if err := json.Unmarshal([]byte(body), &value); err != nil {
+		return body, false
+	}
+
+	var b strings.Builder
+
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(value); err != nil {
+		return body, false
+	}
+
+	return b.String(), true
+}
+
+// indent formats multi-line text so each line is indented under the error it belongs to.
+func indent(text string) string {
+	return "    " + strings.ReplaceAll(text, "\n", "\n    ")
+}
@@ -0,0 +1,180 @@
+package tester
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+)
+
+// snapshotTest returns a test of the given file whose response body is compared with a
+// snapshot.
+func snapshotTest(file, body string, snapshot defs.SnapshotObject) *defs.Test {
+	return &defs.Test{
+		Description: "snapshot",
+		File:        file,
+		Response:    defs.ResponseObject{Body: body, Snapshot: &snapshot},
+	}
+}
+
+// setUpdateSnapshots sets the UpdateSnapshots flag for the rest of a test.
+func setUpdateSnapshots(t *testing.T, update bool) {
+	t.Helper()
+
+	UpdateSnapshots = update
+
+	t.Cleanup(func() { UpdateSnapshots = false })
+}
+
+func TestSnapshotPath(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator), "suite", "users")
+	absolute := filepath.Join(string(filepath.Separator), "golden", "users.txt")
+
+	tests := []struct {
+		name string
+		file string
+		step int
+		want string
+	}{
+		{name: "default", want: filepath.Join(dir, "get.snapshot")},
+		{name: "default for a step", step: 2, want: filepath.Join(dir, "get.step2.snapshot")},
+		{name: "file for a step", file: "login.snapshot", step: 1, want: filepath.Join(dir, "login.snapshot")},
+		{name: "relative", file: "golden/get.txt", want: filepath.Join(dir, "golden", "get.txt")},
+		{name: "absolute", file: absolute, want: absolute},
+		{name: "dictionary", file: "{{NAME}}.snapshot", want: filepath.Join(dir, "alice.snapshot")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dict := dictionary.New()
+			dict.Set("NAME", "alice")
+
+			test := snapshotTest(filepath.Join(dir, "get.json"), "", defs.SnapshotObject{File: tt.file})
+			test.Step = tt.step
+
+			if got := snapshotPath(dict, test); got != tt.want {
+				t.Errorf("snapshotPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		want       string
+		wantIsJSON bool
+	}{
+		{name: "object", body: `{"b": 1, "a": {"d": [1, 2], "c": "<x>"}}`, want: "{\n  \"a\": {\n    \"c\": \"<x>\",\n    \"d\": [\n      1,\n      2\n    ]\n  },\n  \"b\": 1\n}\n", wantIsJSON: true},
+		{name: "array", body: ` [ 1 , "a" ] `, want: "[\n  1,\n  \"a\"\n]\n", wantIsJSON: true},
+		{name: "text", body: "hello, world\n", want: "hello, world\n"},
+		{name: "invalid JSON", body: `{"a": `, want: `{"a": `},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, isJSON := normalize(tt.body)
+			if got != tt.want {
+				t.Errorf("normalize() = %q, want %q", got, tt.want)
+			}
+
+			if isJSON != tt.wantIsJSON {
+				t.Errorf("normalize() isJSON = %v, want %v", isJSON, tt.wantIsJSON)
+			}
+		})
+	}
+}
+
+func TestValidateSnapshot(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot string
+		body     string
+		ignore   []string
+		wantErr  string
+	}{
+		{name: "same JSON", snapshot: "{\n  \"id\": 1,\n  \"name\": \"Alice\"\n}\n", body: `{"name":"Alice","id":1}`},
+		{name: "changed JSON", snapshot: `{"id": 1, "name": "Alice"}`, body: `{"id": 1, "name": "Bob"}`, wantErr: `~ name: "Alice" -> "Bob"`},
+		{name: "added JSON", snapshot: `{"id": 1}`, body: `{"id": 1, "name": "Bob"}`, wantErr: `+ name: "Bob"`},
+		{name: "ignored JSON", snapshot: `{"id": 1, "name": "Alice"}`, body: `{"id": 2, "name": "Alice"}`, ignore: []string{"id"}},
+		{name: "same text", snapshot: "hello\n", body: "hello"},
+		{name: "changed text", snapshot: "hello\n", body: "goodbye", wantErr: "does not match get.snapshot"},
+		{name: "JSON body with a text snapshot", snapshot: "hello", body: `{"id": 1}`, wantErr: "does not match get.snapshot"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "get.snapshot"), []byte(tt.snapshot), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			test := snapshotTest(filepath.Join(dir, "get.json"), tt.body, defs.SnapshotObject{Ignore: tt.ignore})
+
+			err := validateSnapshot(dictionary.New(), test)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("validateSnapshot() error = %v, want nil", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validateSnapshot() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMissingSnapshot(t *testing.T) {
+	test := snapshotTest(filepath.Join(t.TempDir(), "get.json"), `{"id": 1}`, defs.SnapshotObject{})
+
+	err := validateSnapshot(dictionary.New(), test)
+	if err == nil || !strings.Contains(err.Error(), "get.snapshot does not exist, use --update-snapshots") {
+		t.Errorf("validateSnapshot() error = %v, want a missing snapshot error", err)
+	}
+}
+
+func TestUpdateSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "get.snapshot")
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "create JSON", body: `{"name":"Alice","id":1}`, want: "{\n  \"id\": 1,\n  \"name\": \"Alice\"\n}\n"},
+		{name: "replace JSON", body: `{"id":2}`, want: "{\n  \"id\": 2\n}\n"},
+		{name: "replace with text", body: "hello", want: "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := snapshotTest(filepath.Join(dir, "get.json"), tt.body, defs.SnapshotObject{})
+
+			setUpdateSnapshots(t, true)
+
+			if err := validateSnapshot(dictionary.New(), test); err != nil {
+				t.Fatalf("validateSnapshot() error = %v", err)
+			}
+
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("unable to read snapshot, %v", err)
+			}
+
+			if string(b) != tt.want {
+				t.Errorf("snapshot = %q, want %q", b, tt.want)
+			}
+
+			// The snapshot that was written matches the body it was written from.
+			setUpdateSnapshots(t, false)
+
+			if err := validateSnapshot(dictionary.New(), test); err != nil {
+				t.Errorf("validateSnapshot() after update error = %v", err)
+			}
+		})
+	}
+}
@@ -11,13 +11,13 @@ import (
 	"github.com/tucats/apitest/logging"
 )
 
-func executeTask(task defs.Task) error {
+func executeTask(dict *dictionary.Dictionary, task defs.Task) error {
 	var err error
 
 	switch strings.ToLower(task.Command) {
 	case "delete":
 		for _, name := range task.Parameters {
-			name = dictionary.Apply(name)
+			name = dictionary.Apply(dict, name)
 
 			name, err = filepath.Abs(filepath.Clean(name))
 			if err != nil {
@@ -0,0 +1,92 @@
+package tester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+)
+
+// Timeout is the default maximum time to wait for the response to a request, set with the
+// --timeout command line option. If zero, a request waits as long as it takes unless the test
+// gives its own timeout.
+var Timeout time.Duration
+
+// Deadline is the time by which the test run must finish, set with the --deadline command
+// line option. A request still waiting for a response at the deadline is stopped. If zero,
+// the run has no deadline.
+var Deadline time.Time
+
+// requestContext returns the context for the request of a test, which is cancelled when the
+// timeout of the request or the deadline of the run passes. The timeout of the request is
+// also returned, or zero if it has none.
+func requestContext(dict *dictionary.Dictionary, test *defs.Test) (context.Context, context.CancelFunc, time.Duration, error) {
+	timeout := Timeout
+
+	if test.Request.Timeout != "" {
+		var err error
+
+		timeout, err = time.ParseDuration(dictionary.Apply(dict, test.Request.Timeout))
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("invalid request timeout '%s': %v", test.Request.Timeout, err)
+		}
+	}
+
+	deadline := Deadline
+
+	if timeout > 0 {
+		if limit := time.Now().Add(timeout); deadline.IsZero() || limit.Before(deadline) {
+			deadline = limit
+		}
+	}
+
+	if deadline.IsZero() {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		return ctx, cancel, timeout, nil
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+
+	return ctx, cancel, timeout, nil
+}
+
+// requestError forms the error for a request that did not complete. If the request was
+// stopped because its timeout or the deadline of the run passed, the test is marked as timed
+// out and the error says which limit was reached.
+func requestError(ctx context.Context, test *defs.Test, timeout time.Duration, err error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	test.TimedOut = true
+
+	if !Deadline.IsZero() && !time.Now().Before(Deadline) {
+		return fmt.Errorf("request stopped at the deadline of the test run")
+	}
+
+	return fmt.Errorf("request timed out after %v", timeout)
+}
+
+// sleep waits for the given time, or until the deadline of the test run passes if that is
+// sooner. The result is false if the deadline passed, so the caller stops waiting.
+func sleep(duration time.Duration) bool {
+	if Deadline.IsZero() {
+		time.Sleep(duration)
+
+		return true
+	}
+
+	if left := time.Until(Deadline); left < duration {
+		time.Sleep(max(left, 0))
+
+		return false
+	}
+
+	time.Sleep(duration)
+
+	return true
+}
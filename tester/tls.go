@@ -0,0 +1,205 @@
+package tester
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+	"github.com/tucats/apitest/logging"
+	"gopkg.in/resty.v1"
+)
+
+// tlsVersions maps the names of the TLS versions used in tests to their values.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsSettings are the TLS settings used to make a request. Requests with the same settings
+// share a client, so this must remain comparable.
+type tlsSettings struct {
+	verify     bool
+	ca         string
+	cert       string
+	key        string
+	minVersion uint16
+	serverName string
+}
+
+// requestTLS returns the TLS settings for the request of a test. These start with the values
+// of the "TLS_VERIFY", "TLS_CA", "TLS_CERT", "TLS_KEY", "TLS_MIN_VERSION", and "TLS_SERVER_NAME"
+// dictionary values, and are replaced by any fields given in the tls object of the request.
+func requestTLS(dict *dictionary.Dictionary, test *defs.Test) (tlsSettings, error) {
+	var (
+		settings tlsSettings
+		err      error
+	)
+
+	value := func(key string) string {
+		text, _ := dict.Get(key)
+
+		return dictionary.Apply(dict, text)
+	}
+
+	if text := value("TLS_VERIFY"); text != "" {
+		settings.verify, err = strconv.ParseBool(text)
+		if err != nil {
+			return settings, fmt.Errorf("invalid TLS_VERIFY value '%s'", text)
+		}
+	}
+
+	settings.ca = value("TLS_CA")
+	settings.cert = value("TLS_CERT")
+	settings.key = value("TLS_KEY")
+	settings.serverName = value("TLS_SERVER_NAME")
+	version := value("TLS_MIN_VERSION")
+
+	if override := test.Request.TLS; override != nil {
+		if override.Verify != nil {
+			settings.verify = *override.Verify
+		}
+
+		if override.CA != "" {
+			settings.ca = dictionary.Apply(dict, override.CA)
+		}
+
+		if override.Cert != "" {
+			settings.cert = dictionary.Apply(dict, override.Cert)
+			settings.key = dictionary.Apply(dict, override.Key)
+		}
+
+		if override.MinVersion != "" {
+			version = dictionary.Apply(dict, override.MinVersion)
+		}
+
+		if override.ServerName != "" {
+			settings.serverName = dictionary.Apply(dict, override.ServerName)
+		}
+	}
+
+	if (settings.cert == "") != (settings.key == "") {
+		return settings, fmt.Errorf("a TLS client certificate and key must be given together")
+	}
+
+	if version != "" {
+		var ok bool
+
+		settings.minVersion, ok = tlsVersions[version]
+		if !ok {
+			return settings, fmt.Errorf("invalid minimum TLS version '%s'", version)
+		}
+	}
+
+	return settings, nil
+}
+
+// config creates the TLS configuration for the settings, loading the certificate authority
+// and client certificate files.
+func (s tlsSettings) config() (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: !s.verify,
+		MinVersion:         s.minVersion,
+		ServerName:         s.serverName,
+	}
+
+	if s.ca != "" {
+		b, err := os.ReadFile(s.ca)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read TLS certificate authority file, %v", err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no certificates found in TLS certificate authority file %s", s.ca)
+		}
+	}
+
+	if s.cert != "" {
+		certificate, err := tls.LoadX509KeyPair(s.cert, s.key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load TLS client certificate, %v", err)
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+// validateTLS compares the TLS connection used to make the request with the expectations in
+// the tls object of the response, and returns an error for the first that does not match.
+func validateTLS(dict *dictionary.Dictionary, test *defs.Test, resp *resty.Response) error {
+	expected := test.Response.TLS
+
+	if logging.Verbose {
+		fmt.Println("  Validating TLS connection")
+	}
+
+	state := resp.RawResponse.TLS
+	if state == nil {
+		return fmt.Errorf("tls: the request was not made over TLS")
+	}
+
+	if expected.Version != "" {
+		name := dictionary.Apply(dict, expected.Version)
+
+		version, ok := tlsVersions[name]
+		if !ok {
+			return fmt.Errorf("tls: unknown TLS version '%s'", name)
+		}
+
+		if state.Version != version {
+			return fmt.Errorf("tls: expected version %s, got %s", name, tlsVersionName(state.Version))
+		}
+	}
+
+	if expected.Subject == "" && expected.Issuer == "" && expected.ValidFor == "" {
+		return nil
+	}
+
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("tls: the server did not present a certificate")
+	}
+
+	certificate := state.PeerCertificates[0]
+
+	if subject := dictionary.Apply(dict, expected.Subject); !strings.Contains(certificate.Subject.String(), subject) {
+		return fmt.Errorf("tls: expected certificate subject to contain '%s', got '%s'", subject, certificate.Subject)
+	}
+
+	if issuer := dictionary.Apply(dict, expected.Issuer); !strings.Contains(certificate.Issuer.String(), issuer) {
+		return fmt.Errorf("tls: expected certificate issuer to contain '%s', got '%s'", issuer, certificate.Issuer)
+	}
+
+	if expected.ValidFor != "" {
+		duration, err := time.ParseDuration(dictionary.Apply(dict, expected.ValidFor))
+		if err != nil {
+			return fmt.Errorf("tls: invalid validFor duration '%s': %v", expected.ValidFor, err)
+		}
+
+		if time.Now().Add(duration).After(certificate.NotAfter) {
+			return fmt.Errorf("tls: expected certificate to be valid for %v, but it expires %s", duration, certificate.NotAfter.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+// tlsVersionName returns the name used in tests for a TLS version.
+func tlsVersionName(version uint16) string {
+	for name, value := range tlsVersions {
+		if value == version {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("0x%04x", version)
+}
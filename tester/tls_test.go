@@ -0,0 +1,212 @@
+package tester
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+)
+
+// tlsServer starts a TLS server that accepts versions up to the given maximum, or any version
+// if it is zero. The path of a file containing the certificate of the server is returned with
+// it, for use as the certificate authority.
+func tlsServer(t *testing.T, maxVersion uint16) (*httptest.Server, string) {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	server.TLS = &tls.Config{MaxVersion: maxVersion}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	text := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	if err := os.WriteFile(ca, text, 0o600); err != nil {
+		t.Fatalf("unable to write the certificate authority file, %v", err)
+	}
+
+	return server, ca
+}
+
+// tlsRequest makes a GET request to the server with the TLS settings of the request and the
+// expected TLS connection, and returns the result of the test.
+func tlsRequest(dict *dictionary.Dictionary, endpoint string, request *defs.TLSObject, expected *defs.TLSResponseObject) error {
+	test := &defs.Test{
+		Description: "tls",
+		Request:     defs.RequestObject{Method: "GET", Endpoint: endpoint, TLS: request},
+		Response:    defs.ResponseObject{Status: http.StatusOK, TLS: expected},
+	}
+
+	return ExecuteTest(dict, test)
+}
+
+func TestRequestTLS(t *testing.T) {
+	yes, no := true, false
+
+	tests := []struct {
+		name     string
+		values   map[string]string
+		override *defs.TLSObject
+		want     tlsSettings
+		wantErr  bool
+	}{
+		{name: "defaults", want: tlsSettings{}},
+		{
+			name:   "dictionary values",
+			values: map[string]string{"TLS_VERIFY": "true", "TLS_CA": "{{DIR}}/ca.pem", "TLS_MIN_VERSION": "1.2", "TLS_SERVER_NAME": "api.test", "DIR": "/certs"},
+			want:   tlsSettings{verify: true, ca: "/certs/ca.pem", minVersion: tls.VersionTLS12, serverName: "api.test"},
+		},
+		{
+			name:     "request replaces dictionary",
+			values:   map[string]string{"TLS_VERIFY": "true", "TLS_MIN_VERSION": "1.2", "TLS_SERVER_NAME": "api.test"},
+			override: &defs.TLSObject{Verify: &no, MinVersion: "1.3", ServerName: "other.test"},
+			want:     tlsSettings{minVersion: tls.VersionTLS13, serverName: "other.test"},
+		},
+		{
+			name:     "request version from the dictionary",
+			values:   map[string]string{"VERSION": "1.3"},
+			override: &defs.TLSObject{MinVersion: "{{VERSION}}"},
+			want:     tlsSettings{minVersion: tls.VersionTLS13},
+		},
+		{
+			name:     "client certificate",
+			override: &defs.TLSObject{Verify: &yes, Cert: "client.pem", Key: "client.key"},
+			want:     tlsSettings{verify: true, cert: "client.pem", key: "client.key"},
+		},
+		{name: "certificate without key", values: map[string]string{"TLS_CERT": "client.pem"}, wantErr: true},
+		{name: "key without certificate", values: map[string]string{"TLS_KEY": "client.key"}, wantErr: true},
+		{name: "invalid verify", values: map[string]string{"TLS_VERIFY": "sometimes"}, wantErr: true},
+		{name: "invalid version", values: map[string]string{"TLS_MIN_VERSION": "2.0"}, wantErr: true},
+		{name: "invalid request version", override: &defs.TLSObject{MinVersion: "2.0"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dict := dictionary.New()
+			for key, value := range tt.values {
+				dict.Set(key, value)
+			}
+
+			test := &defs.Test{Request: defs.RequestObject{TLS: tt.override}}
+
+			got, err := requestTLS(dict, test)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("requestTLS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requestTLS() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTLSConnection(t *testing.T) {
+	yes := true
+
+	tests := []struct {
+		name       string
+		maxVersion uint16
+		verify     bool
+		useCA      bool
+		serverName string
+		minVersion string
+		wantErr    bool
+	}{
+		{name: "no verification"},
+		{name: "verified with the certificate authority", verify: true, useCA: true},
+		{name: "verified without the certificate authority", verify: true, wantErr: true},
+		{name: "server name in the certificate", verify: true, useCA: true, serverName: "example.com"},
+		{name: "server name not in the certificate", verify: true, useCA: true, serverName: "api.test", wantErr: true},
+		{name: "minimum version accepted", maxVersion: tls.VersionTLS12, minVersion: "1.2"},
+		{name: "minimum version refused", maxVersion: tls.VersionTLS12, minVersion: "1.3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetClients(t, false)
+
+			server, ca := tlsServer(t, tt.maxVersion)
+
+			request := &defs.TLSObject{MinVersion: tt.minVersion, ServerName: tt.serverName}
+			if tt.verify {
+				request.Verify = &yes
+			}
+
+			if tt.useCA {
+				request.CA = ca
+			}
+
+			err := tlsRequest(dictionary.New(), server.URL, request, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExecuteTest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxVersion uint16
+		expected   defs.TLSResponseObject
+		wantErr    string
+	}{
+		{name: "no expectations"},
+		{name: "version", expected: defs.TLSResponseObject{Version: "1.3"}},
+		{name: "wrong version", maxVersion: tls.VersionTLS12, expected: defs.TLSResponseObject{Version: "1.3"}, wantErr: "expected version 1.3, got 1.2"},
+		{name: "older version", maxVersion: tls.VersionTLS12, expected: defs.TLSResponseObject{Version: "1.2"}},
+		{name: "version from the dictionary", maxVersion: tls.VersionTLS12, expected: defs.TLSResponseObject{Version: "{{VERSION}}"}},
+		{name: "unknown version", expected: defs.TLSResponseObject{Version: "2.0"}, wantErr: "unknown TLS version '2.0'"},
+		{name: "subject", expected: defs.TLSResponseObject{Subject: "O={{ORG}}"}},
+		{name: "wrong subject", expected: defs.TLSResponseObject{Subject: "CN=api.test"}, wantErr: "expected certificate subject"},
+		{name: "issuer", expected: defs.TLSResponseObject{Issuer: "O={{ORG}}"}},
+		{name: "wrong issuer", expected: defs.TLSResponseObject{Issuer: "O=Other"}, wantErr: "expected certificate issuer"},
+		{name: "valid long enough", expected: defs.TLSResponseObject{ValidFor: "720h"}},
+		{name: "expires too soon", expected: defs.TLSResponseObject{ValidFor: "876000h"}, wantErr: "expected certificate to be valid"},
+		{name: "invalid validFor", expected: defs.TLSResponseObject{ValidFor: "a month"}, wantErr: "invalid validFor duration"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetClients(t, false)
+
+			server, _ := tlsServer(t, tt.maxVersion)
+
+			dict := dictionary.New()
+			dict.Set("ORG", server.Certificate().Subject.Organization[0])
+			dict.Set("VERSION", "1.2")
+
+			err := tlsRequest(dict, server.URL, nil, &tt.expected)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("ExecuteTest() error = %v, want nil", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("ExecuteTest() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTLSWithoutTLS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	if err := tlsRequest(dictionary.New(), server.URL, nil, &defs.TLSResponseObject{}); err == nil {
+		t.Errorf("ExecuteTest() error = nil, want an error for a request not made over TLS")
+	}
+}
@@ -1,7 +1,9 @@
 package tester
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -11,240 +13,457 @@ import (
 	"github.com/tucats/apitest/parser"
 )
 
-func validateTest(test *defs.Test) error {
-	var (
-		ok  bool
-		err error
-	)
+// validateTest performs the validations in the Tests list against the response body of
+// the test, and returns the list of errors for the validations that failed. Unless the
+// AllErrors flag is set, this stops at the first failed validation.
+func validateTest(dict *dictionary.Dictionary, test *defs.Test) []error {
+	var failures []error
 
 	if logging.Verbose {
 		fmt.Println("  Validating response tests")
 	}
-	// For each test case, validate the text.
+	// For each test case, validate the text, recording the outcome for reporting.
 	for _, t := range test.Tests {
 		if logging.Verbose {
 			fmt.Printf("    Validating %s\n", t.Name)
 		}
 
-		// Apply the dictionary to the value strings
-		expect := dictionary.Apply(t.Value)
+		err := validateItem(dict, test, t)
 
-		value, err := parser.GetItem(test.Response.Body, t.Expression)
+		result := defs.ValidationResult{Name: t.Name, Passed: err == nil}
 		if err != nil {
-			return err
+			result.Error = err.Error()
 		}
 
-		switch t.Operator {
-		case "len", "length":
-			length, err := strconv.Atoi(expect)
-			if err != nil {
-				return err
-			}
+		test.Results = append(test.Results, result)
+
+		if err != nil {
+			failures = append(failures, err)
 
-			if len(value) != length {
-				return fmt.Errorf("%s, %s: expected length to be %d, got %d", test.Description, t.Name, length, len(value))
+			if !AllErrors {
+				break
 			}
+		}
+	}
+
+	return failures
+}
 
-		case "exists":
-			// no action needed for "exists"
-			continue
+// validateItem performs a single validation against the response body of the test.
+func validateItem(dict *dictionary.Dictionary, test *defs.Test, t defs.Validation) error {
+	// Apply the dictionary to the value strings
+	expect := dictionary.Apply(dict, t.Value)
 
-		case "", "eq", ".eq.", "==", "=", "equals", "equal":
-			ok = false
+	values, err := parser.GetValues(test.Response.Body, t.Expression)
+	if err != nil {
+		return fmt.Errorf("%s: %v", t.Name, err)
+	}
 
-			for _, v := range value {
-				if v == expect {
-					ok = true
+	mode := strings.ToLower(t.Mode)
+	if mode == "" && t.Every {
+		mode = "all"
+	}
 
-					break
-				}
+	// Without a quantifier, the operator is applied to the list of values as a whole.
+	if mode == "" {
+		if err := compare(t.Operator, expect, values); err != nil {
+			return fmt.Errorf("%s: %v", t.Name, err)
+		}
+
+		return nil
+	}
+
+	// With a quantifier, the operator is applied to each of the values individually, and
+	// the number of values that pass is checked against the quantifier. If the query finds
+	// no items, such as the keys of an empty object, "all" and "any" fail rather than passing
+	// without checking anything. No items do pass "none".
+	if len(values) == 0 && (mode == "all" || mode == "any") {
+		return fmt.Errorf("%s: query '%s' matched no items", t.Name, t.Expression)
+	}
+
+	var firstFailure, firstPass error
+
+	passed := 0
+
+	for index, v := range values {
+		if err := compare(t.Operator, expect, []interface{}{v}); err != nil {
+			if firstFailure == nil {
+				firstFailure = fmt.Errorf("item %d %v", index, err)
 			}
+		} else {
+			passed++
 
-			if ok {
-				continue
+			if firstPass == nil {
+				b, _ := json.Marshal(v)
+				firstPass = fmt.Errorf("item %d is %s", index, b)
 			}
+		}
+	}
+
+	switch mode {
+	case "all":
+		if passed < len(values) {
+			return fmt.Errorf("%s: expected all items to pass, %d of %d failed, %v", t.Name, len(values)-passed, len(values), firstFailure)
+		}
+
+	case "any":
+		if passed == 0 {
+			return fmt.Errorf("%s: expected any item to pass, all %d failed, %v", t.Name, len(values), firstFailure)
+		}
+
+	case "none":
+		if passed > 0 {
+			return fmt.Errorf("%s: expected no items to pass, %d of %d passed, %v", t.Name, passed, len(values), firstPass)
+		}
 
-			return fmt.Errorf("%s, %s: expected equal to '%s', got '%v'", test.Description, t.Name, t.Value, value)
+	default:
+		return fmt.Errorf("%s: invalid quantifier mode '%s'", t.Name, t.Mode)
+	}
 
-		case "!=", "ne", ".ne.", "<>", "not equal":
-			ok = false
+	return nil
+}
 
-			for _, v := range value {
-				if v != expect {
-					ok = true
+// compare applies the validation operator to the list of values found by the query, using
+// the expected value (after dictionary substitution) as the operand.
+func compare(operator, expect string, values []interface{}) error {
+	var ok bool
 
-					break
-				}
-			}
+	// Most operators compare the string representation of the values.
+	value := parser.Strings(values)
 
-			if ok {
-				continue
+	switch operator {
+	case "len", "length":
+		length, err := strconv.Atoi(expect)
+		if err != nil {
+			return err
+		}
+
+		if len(value) != length {
+			return fmt.Errorf("expected length to be %d, got %d", length, len(value))
+		}
+
+	case "exists":
+		// no action needed for "exists"
+		return nil
+
+	case "", "eq", ".eq.", "==", "=", "equals", "equal":
+		ok = false
+
+		for _, v := range value {
+			if v == expect {
+				ok = true
+
+				break
 			}
+		}
+
+		if ok {
+			return nil
+		}
+
+		return fmt.Errorf("expected equal to '%s', got '%v'", expect, value)
 
-			return fmt.Errorf("%s, %s: expected not finding'%s', got '%s'", test.Description, t.Name, t.Value, value)
+	case "!=", "ne", ".ne.", "<>", "not equal":
+		ok = false
 
-		case "<", "lt", ".lt.", "less than":
-			// See if this can be done as an integer comparison.
-			if len(value) == 0 {
-				return fmt.Errorf("%s, %s: expected a value, found none", test.Description, t.Name)
+		for _, v := range value {
+			if v != expect {
+				ok = true
+
+				break
 			}
+		}
+
+		if ok {
+			return nil
+		}
 
-			v := value[0]
+		return fmt.Errorf("expected not finding'%s', got '%s'", expect, value)
 
-			if iValue, err := strconv.Atoi(v); err == nil {
-				if iExpect, err := strconv.Atoi(expect); err == nil {
-					if iValue >= iExpect {
-						return fmt.Errorf("%s, %s: expected '%s' to be less than '%s'", test.Description, t.Name, value, expect)
-					} else {
-						continue
-					}
+	case "<", "lt", ".lt.", "less than":
+		// See if this can be done as an integer comparison.
+		if len(value) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
+
+		v := value[0]
+
+		if iValue, err := strconv.Atoi(v); err == nil {
+			if iExpect, err := strconv.Atoi(expect); err == nil {
+				if iValue >= iExpect {
+					return fmt.Errorf("expected '%s' to be less than '%s'", value, expect)
+				} else {
+					return nil
 				}
 			}
+		}
 
-			// See if this can be done as an float comparison.
-			if fValue, err := strconv.ParseFloat(v, 64); err == nil {
-				if fExpect, err := strconv.ParseFloat(expect, 64); err == nil {
-					if fValue >= fExpect {
-						return fmt.Errorf("%s, %s: expected '%s' to be less than '%s'", test.Description, t.Name, value, expect)
-					} else {
-						continue
-					}
+		// See if this can be done as an float comparison.
+		if fValue, err := strconv.ParseFloat(v, 64); err == nil {
+			if fExpect, err := strconv.ParseFloat(expect, 64); err == nil {
+				if fValue >= fExpect {
+					return fmt.Errorf("expected '%s' to be less than '%s'", value, expect)
+				} else {
+					return nil
 				}
 			}
+		}
 
-			// If not, just do string comparison.
-			if v >= expect {
-				return fmt.Errorf("%s, %s: expected '%s' to be less than '%s'", test.Description, t.Name, value, expect)
-			}
+		// If not, just do string comparison.
+		if v >= expect {
+			return fmt.Errorf("expected '%s' to be less than '%s'", value, expect)
+		}
 
-		case "<=", "le", ".le.", "less than or equal":
-			if len(value) == 0 {
-				return fmt.Errorf("%s, %s: expected a value, found none", test.Description, t.Name)
-			}
+	case "<=", "le", ".le.", "less than or equal":
+		if len(value) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
 
-			v := value[0]
+		v := value[0]
 
-			// See if this can be done as an integer comparison.
-			if iValue, err := strconv.Atoi(v); err == nil {
-				if iExpect, err := strconv.Atoi(expect); err == nil {
-					if iValue > iExpect {
-						return fmt.Errorf("%s, %s: expected '%s' to be less than or equal to '%s'", test.Description, t.Name, value, expect)
-					} else {
-						continue
-					}
+		// See if this can be done as an integer comparison.
+		if iValue, err := strconv.Atoi(v); err == nil {
+			if iExpect, err := strconv.Atoi(expect); err == nil {
+				if iValue > iExpect {
+					return fmt.Errorf("expected '%s' to be less than or equal to '%s'", value, expect)
+				} else {
+					return nil
 				}
 			}
+		}
 
-			// See if this can be done as an float comparison.
-			if fValue, err := strconv.ParseFloat(v, 64); err == nil {
-				if fExpect, err := strconv.ParseFloat(expect, 64); err == nil {
-					if fValue > fExpect {
-						return fmt.Errorf("%s, %s: expected '%s' to be less than or equal to '%s'", test.Description, t.Name, value, expect)
-					} else {
-						continue
-					}
+		// See if this can be done as an float comparison.
+		if fValue, err := strconv.ParseFloat(v, 64); err == nil {
+			if fExpect, err := strconv.ParseFloat(expect, 64); err == nil {
+				if fValue > fExpect {
+					return fmt.Errorf("expected '%s' to be less than or equal to '%s'", value, expect)
+				} else {
+					return nil
 				}
 			}
+		}
 
-			// If not, just do string comparison.
-			if v < expect {
-				return fmt.Errorf("%s, %s: expected '%s' to be less than or equal to '%s'", test.Description, t.Name, value, expect)
-			}
+		// If not, just do string comparison.
+		if v < expect {
+			return fmt.Errorf("expected '%s' to be less than or equal to '%s'", value, expect)
+		}
 
-		case ">=", "ge", ".ge.", "greater than or equal":
-			if len(value) == 0 {
-				return fmt.Errorf("%s, %s: expected a value, found none", test.Description, t.Name)
-			}
+	case ">=", "ge", ".ge.", "greater than or equal":
+		if len(value) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
 
-			v := value[0]
+		v := value[0]
 
-			// See if this can be done as an integer comparison.
-			if iValue, err := strconv.Atoi(v); err == nil {
-				if iExpect, err := strconv.Atoi(expect); err == nil {
-					if iValue < iExpect {
-						return fmt.Errorf("%s, %s: expected '%s' to be greater than or equal to '%s'", test.Description, t.Name, value, expect)
-					} else {
-						continue
-					}
+		// See if this can be done as an integer comparison.
+		if iValue, err := strconv.Atoi(v); err == nil {
+			if iExpect, err := strconv.Atoi(expect); err == nil {
+				if iValue < iExpect {
+					return fmt.Errorf("expected '%s' to be greater than or equal to '%s'", value, expect)
+				} else {
+					return nil
 				}
 			}
+		}
 
-			// See if this can be done as an float comparison.
-			if fValue, err := strconv.ParseFloat(v, 64); err == nil {
-				if fExpect, err := strconv.ParseFloat(expect, 64); err == nil {
-					if fValue < fExpect {
-						return fmt.Errorf("%s, %s: expected '%s' to be greater than or equal to '%s'", test.Description, t.Name, value, expect)
-					} else {
-						continue
-					}
+		// See if this can be done as an float comparison.
+		if fValue, err := strconv.ParseFloat(v, 64); err == nil {
+			if fExpect, err := strconv.ParseFloat(expect, 64); err == nil {
+				if fValue < fExpect {
+					return fmt.Errorf("expected '%s' to be greater than or equal to '%s'", value, expect)
+				} else {
+					return nil
 				}
 			}
+		}
 
-			// If not, just do string comparison.
-			if v < expect {
-				return fmt.Errorf("%s, %s: expected '%s' to be greater than or equal to '%s'", test.Description, t.Name, value, expect)
-			}
+		// If not, just do string comparison.
+		if v < expect {
+			return fmt.Errorf("expected '%s' to be greater than or equal to '%s'", value, expect)
+		}
 
-		case ">", "gt", ".gt.", "greater than":
-			if len(value) == 0 {
-				return fmt.Errorf("%s, %s: expected a value, found none", test.Description, t.Name)
-			}
+	case ">", "gt", ".gt.", "greater than":
+		if len(value) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
 
-			v := value[0]
+		v := value[0]
 
-			// See if this can be done as an integer comparison.
-			if iValue, err := strconv.Atoi(v); err == nil {
-				if iExpect, err := strconv.Atoi(expect); err == nil {
-					if iValue <= iExpect {
-						return fmt.Errorf("%s, %s: expected '%s' to be greater than '%s'", test.Description, t.Name, value, expect)
-					} else {
-						continue
-					}
+		// See if this can be done as an integer comparison.
+		if iValue, err := strconv.Atoi(v); err == nil {
+			if iExpect, err := strconv.Atoi(expect); err == nil {
+				if iValue <= iExpect {
+					return fmt.Errorf("expected '%s' to be greater than '%s'", value, expect)
+				} else {
+					return nil
 				}
 			}
+		}
 
-			// See if this can be done as an float comparison.
-			if fValue, err := strconv.ParseFloat(v, 64); err == nil {
-				if fExpect, err := strconv.ParseFloat(expect, 64); err == nil {
-					if fValue <= fExpect {
-						return fmt.Errorf("%s, %s: expected '%s' to be greater than '%s'", test.Description, t.Name, value, expect)
-					} else {
-						continue
-					}
+		// See if this can be done as an float comparison.
+		if fValue, err := strconv.ParseFloat(v, 64); err == nil {
+			if fExpect, err := strconv.ParseFloat(expect, 64); err == nil {
+				if fValue <= fExpect {
+					return fmt.Errorf("expected '%s' to be greater than '%s'", value, expect)
+				} else {
+					return nil
 				}
 			}
+		}
 
-			// If not, just do string comparison.
-			if v <= expect {
-				return fmt.Errorf("%s, %s: expected '%s' to be greater than '%s'", test.Description, t.Name, value, expect)
-			}
+		// If not, just do string comparison.
+		if v <= expect {
+			return fmt.Errorf("expected '%s' to be greater than '%s'", value, expect)
+		}
+
+	case "contains", "has", ".contains,", ".has.", "includes":
+		if len(value) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
+
+		v := value[0]
+
+		if !strings.Contains(v, expect) {
+			return fmt.Errorf("expected '%s' to contain '%s'", value, expect)
+		}
+
+	case "not contains", "!contains", ".not contains,":
+		if len(value) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
+
+		v := value[0]
+
+		if strings.Contains(v, expect) {
+			return fmt.Errorf("expected '%s' to contain '%s'", value, expect)
+		}
+
+	case "matches", "match", ".matches.", "=~":
+		if len(value) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
+
+		pattern, err := regexp.Compile(expect)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression '%s': %v", expect, err)
+		}
+
+		if !pattern.MatchString(value[0]) {
+			return fmt.Errorf("expected '%s' to match '%s'", value, expect)
+		}
+
+	case "not matches", "!matches", ".not matches.", "!~":
+		if len(value) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
+
+		pattern, err := regexp.Compile(expect)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression '%s': %v", expect, err)
+		}
 
-		case "contains", "has", ".contains,", ".has.", "includes":
-			if len(value) == 0 {
-				return fmt.Errorf("%s, %s: expected a value, found none", test.Description, t.Name)
+		if pattern.MatchString(value[0]) {
+			return fmt.Errorf("expected '%s' to not match '%s'", value, expect)
+		}
+
+	case "startsWith", "startswith", "starts with", "prefix":
+		if len(value) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
+
+		if !strings.HasPrefix(value[0], expect) {
+			return fmt.Errorf("expected '%s' to start with '%s'", value, expect)
+		}
+
+	case "endsWith", "endswith", "ends with", "suffix":
+		if len(value) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
+
+		if !strings.HasSuffix(value[0], expect) {
+			return fmt.Errorf("expected '%s' to end with '%s'", value, expect)
+		}
+
+	case "eqi", ".eqi.", "equalsIgnoreCase", "equals ignore case", "equal ignore case":
+		for _, v := range value {
+			if strings.EqualFold(v, expect) {
+				return nil
 			}
+		}
 
-			v := value[0]
+		return fmt.Errorf("expected equal (ignoring case) to '%s', got '%v'", expect, value)
 
-			if !strings.Contains(v, expect) {
-				return fmt.Errorf("%s, %s: expected '%s' to contain '%s'", test.Description, t.Name, value, t.Value)
+	case "empty", "is empty":
+		for _, v := range value {
+			if !isEmpty(v) {
+				return fmt.Errorf("expected an empty value, got '%v'", value)
 			}
+		}
 
-		case "not contains", "!contains", ".not contains,":
-			if len(value) == 0 {
-				return fmt.Errorf("%s, %s: expected a value, found none", test.Description, t.Name)
+	case "not empty", "!empty", "is not empty":
+		// This is the negation of "empty", so it passes if any of the values is not empty.
+		for _, v := range value {
+			if !isEmpty(v) {
+				return nil
 			}
+		}
+
+		return fmt.Errorf("expected a non-empty value, got '%v'", value)
 
-			v := value[0]
+	case "type", "is type", ".type.":
+		if len(values) == 0 {
+			return fmt.Errorf("expected a value, found none")
+		}
 
-			if strings.Contains(v, expect) {
-				return fmt.Errorf("%s, %s: expected '%s' to contain '%s'", test.Description, t.Name, value, t.Value)
+		for _, v := range values {
+			if !parser.IsType(v, expect) {
+				return fmt.Errorf("expected type '%s', got '%s'", expect, parser.TypeName(v))
 			}
+		}
 
-		default:
-			return fmt.Errorf("%s, %s: invalid results comparison operator '%s'", test.Description, t.Name, t.Operator)
+	case "===", "teq", ".teq.", "typed equal":
+		for _, v := range values {
+			if parser.Equal(v, expect) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("expected typed equal to %s, got %s", expect, jsonText(values))
+
+	case "!==", "tne", ".tne.", "typed not equal":
+		for _, v := range values {
+			if !parser.Equal(v, expect) {
+				return nil
+			}
 		}
+
+		return fmt.Errorf("expected typed not equal to %s, got %s", expect, jsonText(values))
+
+	default:
+		return fmt.Errorf("invalid results comparison operator '%s'", operator)
+	}
+
+	return nil
+}
+
+// jsonText formats a list of values as JSON, so the types of the values are visible in
+// error messages.
+func jsonText(values []interface{}) string {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Sprintf("%v", values)
+	}
+
+	return string(b)
+}
+
+// isEmpty determines if the string form of a query result is an empty value. This is
+// an empty string, or the formatted value of an empty array, an empty object, or null.
+func isEmpty(v string) bool {
+	switch v {
+	case "", "[]", "map[]", "<nil>":
+		return true
 	}
 
-	return err
+	return false
 }
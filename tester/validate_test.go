@@ -0,0 +1,176 @@
+package tester
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		operator string
+		expect   string
+		values   []interface{}
+		wantErr  bool
+	}{
+		{name: "matches", operator: "matches", expect: "^[a-z]+-[0-9]+$", values: []interface{}{"order-42"}},
+		{name: "matches fails", operator: "matches", expect: "^[0-9]+$", values: []interface{}{"order-42"}, wantErr: true},
+		{name: "matches a number", operator: "=~", expect: `^\d+$`, values: []interface{}{float64(42)}},
+		{name: "matches nothing found", operator: "matches", expect: ".*", wantErr: true},
+		{name: "matches invalid expression", operator: "matches", expect: "[a-", values: []interface{}{"a"}, wantErr: true},
+		{name: "not matches", operator: "not matches", expect: "^[0-9]+$", values: []interface{}{"order-42"}},
+		{name: "not matches fails", operator: "!~", expect: "order", values: []interface{}{"order-42"}, wantErr: true},
+		{name: "not matches invalid expression", operator: "not matches", expect: "(", values: []interface{}{"a"}, wantErr: true},
+		{name: "startsWith", operator: "startsWith", expect: "https://", values: []interface{}{"https://example.com"}},
+		{name: "startsWith fails", operator: "prefix", expect: "http://", values: []interface{}{"https://example.com"}, wantErr: true},
+		{name: "endsWith", operator: "endsWith", expect: ".com", values: []interface{}{"https://example.com"}},
+		{name: "endsWith fails", operator: "suffix", expect: ".org", values: []interface{}{"https://example.com"}, wantErr: true},
+		{name: "endsWith nothing found", operator: "endsWith", expect: "", wantErr: true},
+		{name: "eqi", operator: "eqi", expect: "ACTIVE", values: []interface{}{"Active"}},
+		{name: "eqi fails", operator: "eqi", expect: "inactive", values: []interface{}{"Active"}, wantErr: true},
+		{name: "empty string", operator: "empty", values: []interface{}{""}},
+		{name: "empty array", operator: "empty", values: []interface{}{[]interface{}{}}},
+		{name: "empty object", operator: "empty", values: []interface{}{map[string]interface{}{}}},
+		{name: "empty null", operator: "empty", values: []interface{}{nil}},
+		{name: "empty nothing found", operator: "empty"},
+		{name: "empty fails", operator: "empty", values: []interface{}{"x"}, wantErr: true},
+		{name: "empty fails if any item is not empty", operator: "empty", values: []interface{}{"", "x"}, wantErr: true},
+		{name: "not empty", operator: "not empty", values: []interface{}{"x"}},
+		{name: "not empty array", operator: "not empty", values: []interface{}{[]interface{}{1.0}}},
+		{name: "not empty fails", operator: "not empty", values: []interface{}{""}, wantErr: true},
+		{name: "not empty nothing found", operator: "not empty", wantErr: true},
+		{name: "not empty if any item is not empty", operator: "not empty", values: []interface{}{"", "x"}},
+		{name: "not empty in any position", operator: "not empty", values: []interface{}{"x", ""}},
+		{name: "not empty fails if all items are empty", operator: "!empty", values: []interface{}{"", nil}, wantErr: true},
+		{name: "invalid operator", operator: "like", expect: "x", values: []interface{}{"x"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := compare(tt.operator, tt.expect, tt.values); (err != nil) != tt.wantErr {
+				t.Errorf("compare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmptyNegation(t *testing.T) {
+	// Whatever the values, exactly one of "empty" and "not empty" passes.
+	lists := [][]interface{}{
+		nil,
+		{""},
+		{"x"},
+		{"", "x"},
+		{"x", ""},
+		{"", nil, []interface{}{}},
+		{map[string]interface{}{"a": 1.0}, ""},
+	}
+
+	for _, values := range lists {
+		empty := compare("empty", "", values) == nil
+		notEmpty := compare("not empty", "", values) == nil
+
+		if empty == notEmpty {
+			t.Errorf("compare(%v) empty = %v, not empty = %v, want exactly one to pass", values, empty, notEmpty)
+		}
+	}
+}
+
+func TestCompareTypes(t *testing.T) {
+	object := map[string]interface{}{"id": 5.0}
+	array := []interface{}{1.0, "a"}
+
+	tests := []struct {
+		name     string
+		operator string
+		expect   string
+		values   []interface{}
+		wantErr  bool
+	}{
+		{name: "string", operator: "type", expect: "string", values: []interface{}{"5"}},
+		{name: "string mismatch", operator: "type", expect: "string", values: []interface{}{5.0}, wantErr: true},
+		{name: "number", operator: "type", expect: "number", values: []interface{}{5.5}},
+		{name: "number mismatch", operator: "type", expect: "number", values: []interface{}{"5.5"}, wantErr: true},
+		{name: "integer", operator: "type", expect: "integer", values: []interface{}{5.0}},
+		{name: "integer mismatch", operator: "type", expect: "int", values: []interface{}{5.5}, wantErr: true},
+		{name: "boolean", operator: "type", expect: "boolean", values: []interface{}{false}},
+		{name: "bool", operator: "type", expect: "bool", values: []interface{}{true}},
+		{name: "boolean mismatch", operator: "type", expect: "boolean", values: []interface{}{"true"}, wantErr: true},
+		{name: "null", operator: "type", expect: "null", values: []interface{}{nil}},
+		{name: "null mismatch", operator: "type", expect: "null", values: []interface{}{""}, wantErr: true},
+		{name: "array", operator: "type", expect: "array", values: []interface{}{array}},
+		{name: "array mismatch", operator: "type", expect: "array", values: []interface{}{object}, wantErr: true},
+		{name: "object", operator: "type", expect: "object", values: []interface{}{object}},
+		{name: "map", operator: "is type", expect: "Map", values: []interface{}{object}},
+		{name: "object mismatch", operator: "type", expect: "object", values: []interface{}{array}, wantErr: true},
+		{name: "every item has the type", operator: "type", expect: "number", values: []interface{}{1.0, 2.0}},
+		{name: "one item has another type", operator: "type", expect: "number", values: []interface{}{1.0, "2"}, wantErr: true},
+		{name: "type nothing found", operator: "type", expect: "string", wantErr: true},
+		{name: "unknown type", operator: "type", expect: "date", values: []interface{}{"2025-06-01"}, wantErr: true},
+		{name: "typed equal number", operator: "===", expect: "5", values: []interface{}{5.0}},
+		{name: "typed equal number to string", operator: "===", expect: "5", values: []interface{}{"5"}, wantErr: true},
+		{name: "typed equal string", operator: "===", expect: `"5"`, values: []interface{}{"5"}},
+		{name: "typed equal string to number", operator: "===", expect: `"5"`, values: []interface{}{5.0}, wantErr: true},
+		{name: "typed equal boolean", operator: "teq", expect: "true", values: []interface{}{true}},
+		{name: "typed equal null", operator: "===", expect: "null", values: []interface{}{nil}},
+		{name: "typed equal null to empty string", operator: "===", expect: "null", values: []interface{}{""}, wantErr: true},
+		{name: "typed equal object", operator: "===", expect: `{"id": 5}`, values: []interface{}{object}},
+		{name: "typed equal text that is not JSON", operator: "===", expect: "abc", values: []interface{}{"abc"}},
+		{name: "typed equal any item", operator: "===", expect: "2", values: []interface{}{1.0, 2.0}},
+		{name: "typed equal nothing found", operator: "===", expect: "5", wantErr: true},
+		{name: "typed not equal", operator: "!==", expect: "5", values: []interface{}{"5"}},
+		{name: "typed not equal fails", operator: "tne", expect: "5", values: []interface{}{5.0}, wantErr: true},
+		{name: "typed not equal null", operator: "!==", expect: "null", values: []interface{}{"null"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := compare(tt.operator, tt.expect, tt.values); (err != nil) != tt.wantErr {
+				t.Errorf("compare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateItem(t *testing.T) {
+	body := `{ "items": [ { "status": "active", "deleted": false }, { "status": "closed", "deleted": false } ], "empty": [], "meta": {} }`
+
+	tests := []struct {
+		name       string
+		validation defs.Validation
+		wantErr    string
+	}{
+		{name: "all pass", validation: defs.Validation{Expression: "items.*.deleted", Value: "false", Mode: "all"}},
+		{name: "all with a failure", validation: defs.Validation{Expression: "items.*.status", Value: "active", Mode: "all"}, wantErr: "1 of 2 failed"},
+		{name: "every", validation: defs.Validation{Expression: "items.*.status", Value: "active", Every: true}, wantErr: "1 of 2 failed"},
+		{name: "any pass", validation: defs.Validation{Expression: "items.*.status", Value: "closed", Mode: "any"}},
+		{name: "any fail", validation: defs.Validation{Expression: "items.*.status", Value: "open", Mode: "Any"}, wantErr: "all 2 failed"},
+		{name: "none pass", validation: defs.Validation{Expression: "items.*.deleted", Value: "true", Mode: "none"}},
+		{name: "none fail", validation: defs.Validation{Expression: "items.*.status", Value: "closed", Mode: "none"}, wantErr: "1 of 2 passed"},
+		{name: "all of no items", validation: defs.Validation{Expression: "keys(meta)", Value: "id", Mode: "all"}, wantErr: "matched no items"},
+		{name: "every of no items", validation: defs.Validation{Expression: "keys(meta)", Operator: "type", Value: "string", Every: true}, wantErr: "matched no items"},
+		{name: "any of no items", validation: defs.Validation{Expression: "keys(meta)", Value: "id", Mode: "any"}, wantErr: "matched no items"},
+		{name: "none of no items", validation: defs.Validation{Expression: "keys(meta)", Value: "id", Mode: "none"}},
+		{name: "wildcard of an empty array", validation: defs.Validation{Expression: "empty.*.status", Value: "active", Mode: "all"}, wantErr: "not found"},
+		{name: "invalid mode", validation: defs.Validation{Expression: "items.*.status", Value: "active", Mode: "most"}, wantErr: "invalid quantifier mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.validation.Name = tt.name
+			test := &defs.Test{Response: defs.ResponseObject{Body: body}}
+
+			err := validateItem(dictionary.New(), test, tt.validation)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("validateItem() error = %v, want nil", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validateItem() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package tester
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+	"github.com/tucats/apitest/logging"
+	"gopkg.in/resty.v1"
+)
+
+const (
+	defaultWaitInterval = time.Second
+	defaultWaitTimeout  = 30 * time.Second
+)
+
+// waitUntil makes the request of the test again, at the interval given in the WaitUntil
+// object of the test, until all of its validations pass. The response that met the condition
+// is returned. If the condition is not met by a last poll at the timeout, the error includes
+// the last response body received.
+func waitUntil(dict *dictionary.Dictionary, test *defs.Test, resp *resty.Response) (*resty.Response, error) {
+	wait := test.WaitUntil
+
+	interval, err := waitDuration(dict, "interval", wait.Interval, defaultWaitInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := waitDuration(dict, "timeout", wait.Timeout, defaultWaitTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for poll := 1; ; poll++ {
+		err = waitCondition(dict, test)
+		if err == nil {
+			return resp, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("waitUntil: condition not met after %v, %v, last body: %s", timeout, err, test.Response.Body)
+		}
+
+		// The last poll is made at the timeout, even if that is sooner than the interval.
+		pause := min(interval, remaining)
+
+		if logging.Verbose {
+			fmt.Printf("  Poll %d did not meet the condition, waiting %v: %v\n", poll, pause, err)
+		}
+
+		// Stop waiting if the deadline of the test run passes first.
+		if !sleep(pause) {
+			test.TimedOut = true
+
+			return nil, fmt.Errorf("waitUntil: condition not met at the deadline of the test run, %v, last body: %s", err, test.Response.Body)
+		}
+
+		resp, err = sendRequest(dict, test)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitCondition checks each of the validations of the WaitUntil object against the response
+// body of the test, and returns the first one that fails.
+func waitCondition(dict *dictionary.Dictionary, test *defs.Test) error {
+	for _, t := range test.WaitUntil.Tests {
+		if err := validateItem(dict, test, t); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// waitDuration parses one of the durations of the WaitUntil object, after applying the
+// dictionary to it. If the duration is empty, the default value is returned.
+func waitDuration(dict *dictionary.Dictionary, name, text string, defaultValue time.Duration) (time.Duration, error) {
+	if text == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(dictionary.Apply(dict, text))
+	if err != nil {
+		return 0, fmt.Errorf("invalid waitUntil %s '%s': %v", name, text, err)
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,111 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tucats/apitest/defs"
+	"github.com/tucats/apitest/dictionary"
+)
+
+// waitTest returns a test that polls the server until its request count reaches the value.
+func waitTest(endpoint, count, interval, timeout string) *defs.Test {
+	return &defs.Test{
+		Description: "wait",
+		Request:     defs.RequestObject{Method: "GET", Endpoint: endpoint},
+		Response:    defs.ResponseObject{Status: http.StatusOK},
+		WaitUntil: &defs.WaitUntil{
+			Tests:    []defs.Validation{{Name: "count", Expression: "count", Operator: "ge", Value: count}},
+			Interval: interval,
+			Timeout:  timeout,
+		},
+	}
+}
+
+func TestWaitUntil(t *testing.T) {
+	tests := []struct {
+		name         string
+		count        string
+		interval     string
+		timeout      string
+		wantRequests int32
+		wantErr      string
+	}{
+		{name: "met at once", count: "1", interval: "1ms", timeout: "1s", wantRequests: 1},
+		{name: "met after polling", count: "3", interval: "1ms", timeout: "1s", wantRequests: 3},
+		{name: "met at the timeout", count: "3", interval: "40ms", timeout: "50ms", wantRequests: 3},
+		{name: "timeout", count: "1000", interval: "20ms", timeout: "50ms", wantErr: "condition not met after 50ms"},
+		{name: "timeout after a last poll", count: "1000", interval: "40ms", timeout: "50ms", wantRequests: 3, wantErr: "condition not met after 50ms"},
+		{name: "invalid interval", count: "1", interval: "often", wantRequests: 1, wantErr: "invalid waitUntil interval"},
+		{name: "invalid timeout", count: "1", timeout: "later", wantRequests: 1, wantErr: "invalid waitUntil timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, count := countServer(t, http.StatusOK)
+			test := waitTest(server.URL, tt.count, tt.interval, tt.timeout)
+
+			err := ExecuteTest(dictionary.New(), test)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("ExecuteTest() error = %v, want nil", err)
+			}
+
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("ExecuteTest() error = %v, want %s", err, tt.wantErr)
+			}
+
+			if tt.wantRequests > 0 && count.Load() != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", count.Load(), tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestWaitUntilTimeoutBody(t *testing.T) {
+	server, count := countServer(t, http.StatusOK)
+	test := waitTest(server.URL, "1000", "10ms", "100ms")
+
+	err := ExecuteTest(dictionary.New(), test)
+	if err == nil {
+		t.Fatalf("ExecuteTest() error = nil, want a timeout error")
+	}
+
+	// The error includes the body of the last response received.
+	if want := fmt.Sprintf(`"count": %d`, count.Load()); !strings.Contains(err.Error(), want) {
+		t.Errorf("ExecuteTest() error = %v, want the last body %s", err, want)
+	}
+
+	if test.TimedOut {
+		t.Errorf("TimedOut = true, want false")
+	}
+}
+
+func TestWaitUntilDeadline(t *testing.T) {
+	server, count := countServer(t, http.StatusOK)
+	test := waitTest(server.URL, "1000", "10s", "1m")
+
+	Deadline = time.Now().Add(50 * time.Millisecond)
+	t.Cleanup(func() { Deadline = time.Time{} })
+
+	start := time.Now()
+
+	err := ExecuteTest(dictionary.New(), test)
+	if err == nil || !strings.Contains(err.Error(), "deadline of the test run") {
+		t.Errorf("ExecuteTest() error = %v, want a deadline error", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("ExecuteTest() took %v, want it to stop at the deadline", elapsed)
+	}
+
+	if !test.TimedOut {
+		t.Errorf("TimedOut = false, want true")
+	}
+
+	if got := count.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}